@@ -0,0 +1,160 @@
+package consumer
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// errRefresh is returned by Authenticators that have no way of refreshing
+// their credentials.
+var errRefresh = errors.New("consumer: credentials cannot be refreshed")
+
+// ErrInvalidOrigin is returned by NewAuthenticator when href does not have a
+// scheme and a host, and therefore has no origin to scope credentials to.
+var ErrInvalidOrigin = errors.New("consumer: href must have a scheme and host")
+
+// Authenticator adds credentials to the requests made by a Fetcher. An
+// Authenticator is scoped to a single origin via NewAuthenticator, so the
+// credentials it adds are never sent to the other origins a document links
+// to.
+type Authenticator interface {
+	// Authenticate adds credentials to req. If refresh is true the server
+	// rejected the previous credentials with a 401 and a WWW-Authenticate
+	// challenge, and the credentials should be refreshed before being added.
+	// If the credentials cannot be refreshed an error should be returned, in
+	// which case the 401 response is returned to the caller.
+	Authenticate(req *http.Request, refresh bool) error
+}
+
+// AuthenticatorFunc is an adapter to allow the use of ordinary functions as
+// an Authenticator, it is useful for signing requests.
+type AuthenticatorFunc func(req *http.Request, refresh bool) error
+
+// Authenticate calls fn(req, refresh).
+func (fn AuthenticatorFunc) Authenticate(req *http.Request, refresh bool) error {
+	return fn(req, refresh)
+}
+
+// NewAuthenticator creates an Option that adds an Authenticator for the
+// origin (scheme, host, and port) of href to a Fetcher. Only requests made to
+// that origin, including redirects, are authenticated by a.
+func NewAuthenticator(href string, a Authenticator) (Option, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, ErrInvalidOrigin
+	}
+	o := origin(u)
+	return func(i interface{}) error {
+		f, ok := i.(*fetcher)
+		if !ok {
+			return ErrTypeUnknown
+		}
+		if f.auth == nil {
+			f.auth = make(map[string]Authenticator)
+		}
+		f.auth[o] = a
+		return nil
+	}, nil
+}
+
+// BasicAuth returns an Authenticator that sets the Authorization header of
+// each request using HTTP Basic Authentication.
+func BasicAuth(username, password string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request, refresh bool) error {
+		if refresh {
+			return errRefresh
+		}
+		req.SetBasicAuth(username, password)
+		return nil
+	})
+}
+
+// BearerToken returns an Authenticator that sets the Authorization header of
+// each request to a bearer token. The token is retrieved by calling token,
+// which is called again with refresh set to true when the server rejects the
+// current token. The token is cached between requests.
+func BearerToken(token func(refresh bool) (string, error)) Authenticator {
+	return &bearer{token: token}
+}
+
+type bearer struct {
+	token func(refresh bool) (string, error)
+
+	mu     sync.Mutex
+	cached string
+}
+
+func (b *bearer) Authenticate(req *http.Request, refresh bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cached == "" || refresh {
+		tok, err := b.token(refresh)
+		if err != nil {
+			return err
+		}
+		b.cached = tok
+	}
+	req.Header.Set("Authorization", "Bearer "+b.cached)
+	return nil
+}
+
+// authTransport authenticates each request, including each redirect, for the
+// origin it is sent to. The credentials are added to a clone of the request so
+// they are not copied into redirects to other origins.
+type authTransport struct {
+	base http.RoundTripper
+	auth map[string]Authenticator
+}
+
+func (t authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	a, ok := t.auth[origin(req.URL)]
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	authReq := req.Clone(req.Context())
+	err := a.Authenticate(authReq, false)
+	if err != nil {
+		// A RoundTripper must close the body of the request, even on
+		// errors.
+		closeBody(req)
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(authReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") == "" {
+		return resp, nil
+	}
+
+	// Retry once with refreshed credentials, this requires the body to be
+	// replayable.
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+		retry.Body, err = req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+	}
+	if a.Authenticate(retry, true) != nil {
+		closeBody(retry)
+		return resp, nil
+	}
+	resp.Body.Close()
+	return t.base.RoundTrip(retry)
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
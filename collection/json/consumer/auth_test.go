@@ -0,0 +1,143 @@
+package consumer
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuthenticator(t *testing.T) {
+	doc := `{"collection":{"version":"1.0"}}`
+	var thirdParty string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		thirdParty = r.Header.Get("Authorization")
+		w.Write([]byte(doc))
+	}))
+	defer other.Close()
+
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		got = append(got, auth)
+		switch {
+		case r.URL.Path == "/away":
+			http.Redirect(w, r, other.URL, http.StatusFound)
+		case auth == "Bearer expired":
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.Write([]byte(doc))
+		}
+	}))
+	defer srv.Close()
+
+	p := DefaultPolicy()
+	p.AllowPrivate = true
+	tokens := []string{"expired", "fresh"}
+	authOpt, err := NewAuthenticator(srv.URL, BearerToken(func(refresh bool) (string, error) {
+		tok := tokens[0]
+		tokens = tokens[1:]
+		return tok, nil
+	}))
+	if err != nil {
+		t.Errorf("Unexpected error from NewAuthenticator: %v", err)
+	}
+	f, err := NewFetcher(NewPolicy(p), authOpt)
+	if err != nil {
+		t.Errorf("Unexpected error from NewFetcher: %v", err)
+	}
+
+	// Should refresh the token and retry once when answered with a 401
+	_, err = f.Get(srv.URL)
+	if err != nil {
+		t.Errorf("Unexpected error from Get: %v", err)
+	}
+	want := []string{"Bearer expired", "Bearer fresh"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Error("Should refresh the token and retry once when answered with a 401")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should not send credentials to other origins
+	_, err = f.Get(srv.URL + "/away")
+	if err != nil {
+		t.Errorf("Unexpected error from Get: %v", err)
+	}
+	if thirdParty != "" {
+		t.Error("Should not send credentials to other origins")
+		t.Errorf("Wanted no Authorization header, got %v", thirdParty)
+	}
+
+	// Should return the 401 when credentials cannot be refreshed
+	authOpt, err = NewAuthenticator(srv.URL, AuthenticatorFunc(func(req *http.Request, refresh bool) error {
+		if refresh {
+			return errRefresh
+		}
+		req.Header.Set("Authorization", "Bearer expired")
+		return nil
+	}))
+	if err != nil {
+		t.Errorf("Unexpected error from NewAuthenticator: %v", err)
+	}
+	f, err = NewFetcher(NewPolicy(p), authOpt)
+	if err != nil {
+		t.Errorf("Unexpected error from NewFetcher: %v", err)
+	}
+	got = nil
+	f.Get(srv.URL)
+	if len(got) != 1 {
+		t.Error("Should return the 401 when credentials cannot be refreshed")
+		t.Errorf("Wanted 1 request, got %d", len(got))
+	}
+
+	// Should close the request body when authentication fails
+	authOpt, _ = NewAuthenticator(srv.URL, AuthenticatorFunc(func(req *http.Request, refresh bool) error {
+		return errRefresh
+	}))
+	f, err = NewFetcher(NewPolicy(p), authOpt)
+	if err != nil {
+		t.Errorf("Unexpected error from NewFetcher: %v", err)
+	}
+	body := &closeRecorder{Reader: strings.NewReader(doc)}
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, body)
+	_, err = f.Do(req)
+	if !errors.Is(err, errRefresh) || !body.closed {
+		t.Error("Should close the request body when authentication fails")
+		t.Errorf("Wanted %v and a closed body, got %v and %v", errRefresh, err, body.closed)
+	}
+
+	// Should not be able to scope credentials to an href without an origin
+	for _, href := range []string{"example.com", "/friends/", "http:///friends/"} {
+		_, err = NewAuthenticator(href, BasicAuth("foo", "bar"))
+		if err != ErrInvalidOrigin {
+			t.Error("Should not be able to scope credentials to an href without an origin")
+			t.Errorf("Wanted %v, got %v for %v", ErrInvalidOrigin, err, href)
+		}
+	}
+
+	// Should set basic auth credentials
+	req = httptest.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header = make(http.Header)
+	err = BasicAuth("foo", "bar").Authenticate(req, false)
+	if err != nil {
+		t.Errorf("Unexpected error from Authenticate: %v", err)
+	}
+	user, pass, ok := req.BasicAuth()
+	if !ok || user != "foo" || pass != "bar" {
+		t.Error("Should set basic auth credentials")
+		t.Errorf("Wanted foo:bar, got %s:%s", user, pass)
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
@@ -4,25 +4,37 @@ import (
 	"encoding/json"
 
 	"github.com/skriptble/hyper/collection/json"
+	"github.com/skriptble/hyper/collection/json/internal/jsonext"
 )
 
-// Collection represents a Collection+JSON document.
+// Collection represents a Collection+JSON document. Marshaling a Collection
+// into JSON produces a document semantically equivalent to the one it was
+// created from, including any properties added by extensions.
 type Collection interface {
 	Query(rels ...string) []Query
+	// Err returns the error described by the document, as an *Error, or nil
+	// if the document has neither an error nor an errors extension.
+	Err() error
+
+	json.Marshaler
 }
 
 type index map[string][]int
 
+// wrapper is the top level object of a Collection+JSON document. It is the
+// implementation of Collection returned by NewCollection.
+type wrapper struct {
+	C collection `json:"collection"`
+}
+
 // NewCollection converts a slice of bytes into a Collection.
 func NewCollection(b []byte) (Collection, error) {
-	var wrapper struct {
-		collection `json:"collection"`
-	}
-	err := json.Unmarshal(b, &wrapper)
+	w := new(wrapper)
+	err := json.Unmarshal(b, w)
 	if err != nil {
 		return nil, err
 	}
-	c := wrapper.collection
+	c := w.C
 	c.links = make(index)
 	c.queries = make(index)
 	// Build the indexes
@@ -31,21 +43,55 @@ func NewCollection(b []byte) (Collection, error) {
 		c.queries[q.Name] = append(c.queries[q.Name], idx)
 	}
 
-	return c, nil
+	return wrapper{C: c}, nil
+}
+
+// encoding/json Marshaler implementation
+func (w wrapper) MarshalJSON() ([]byte, error) {
+	document := struct {
+		C collection `json:"collection"`
+	}{
+		C: w.C,
+	}
+	return json.Marshal(document)
+}
+
+func (w wrapper) Query(rels ...string) []Query {
+	return w.C.Query(rels...)
+}
+
+func (w wrapper) Err() error {
+	return w.C.Err()
 }
 
 type collection struct {
-	Version  cj.Version `json:"version"`
-	Href     string     `json:"href"`
-	Links    []link     `json:"links"`
-	Items    []item     `json:"items"`
-	Queries  []query    `json:"queries"`
-	Template template   `json:"template"`
-	Error    cjError    `json:"error"`
+	Version  cj.Version `json:"version,omitempty"`
+	Href     string     `json:"href,omitempty"`
+	Links    []link     `json:"links,omitempty"`
+	Items    []item     `json:"items,omitempty"`
+	Queries  []query    `json:"queries,omitempty"`
+	Template *template  `json:"template,omitempty"`
+	Error    *cjError   `json:"error,omitempty"`
+
+	// Errors holds the errors extension, a list of errors tied to the name
+	// of a datum.
+	Errors []cjFieldError `json:"errors,omitempty"`
 
 	// Indexes for the Queries and Links slices
 	queries index
 	links   index
+
+	ext jsonext.Extensions
+}
+
+func (c *collection) UnmarshalJSON(b []byte) error {
+	type plain collection
+	return jsonext.Unmarshal(b, (*plain)(c), &c.ext)
+}
+
+func (c collection) MarshalJSON() ([]byte, error) {
+	type plain collection
+	return jsonext.Marshal(plain(c), c.ext)
 }
 
 type link struct {
@@ -54,12 +100,36 @@ type link struct {
 	Name   string `json:"name,omitempty"`
 	Render string `json:"render,omitempty"`
 	Prompt string `json:"prompt,omitempty"`
+
+	ext jsonext.Extensions
+}
+
+func (l *link) UnmarshalJSON(b []byte) error {
+	type plain link
+	return jsonext.Unmarshal(b, (*plain)(l), &l.ext)
+}
+
+func (l link) MarshalJSON() ([]byte, error) {
+	type plain link
+	return jsonext.Marshal(plain(l), l.ext)
 }
 
 type item struct {
-	Href  string  `json:"href"`
+	Href  string  `json:"href,omitempty"`
 	Data  []datum `json:"data,omitempty"`
 	Links []link  `json:"links,omitempty"`
+
+	ext jsonext.Extensions
+}
+
+func (i *item) UnmarshalJSON(b []byte) error {
+	type plain item
+	return jsonext.Unmarshal(b, (*plain)(i), &i.ext)
+}
+
+func (i item) MarshalJSON() ([]byte, error) {
+	type plain item
+	return jsonext.Marshal(plain(i), i.ext)
 }
 
 type query struct {
@@ -68,20 +138,70 @@ type query struct {
 	Name      string  `json:"name,omitempty"`
 	PromptStr string  `json:"prompt,omitempty"`
 	Data      []datum `json:"data,omitempty"`
+
+	ext jsonext.Extensions
+}
+
+func (q *query) UnmarshalJSON(b []byte) error {
+	type plain query
+	return jsonext.Unmarshal(b, (*plain)(q), &q.ext)
+}
+
+func (q query) MarshalJSON() ([]byte, error) {
+	type plain query
+	return jsonext.Marshal(plain(q), q.ext)
 }
 
 type template struct {
 	Data []datum `json:"data"`
+
+	ext jsonext.Extensions
+}
+
+func (t *template) UnmarshalJSON(b []byte) error {
+	type plain template
+	return jsonext.Unmarshal(b, (*plain)(t), &t.ext)
+}
+
+func (t template) MarshalJSON() ([]byte, error) {
+	type plain template
+	return jsonext.Marshal(plain(t), t.ext)
 }
 
+// datum keeps its value as raw JSON since the value of a datum can be any
+// JSON value, not only a string.
 type datum struct {
-	Name   string `json:"name"`
-	Value  string `json:"value,omitempty"`
-	Prompt string `json:"prompt,omitempty"`
+	Name   string          `json:"name"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	Prompt string          `json:"prompt,omitempty"`
+
+	ext jsonext.Extensions
+}
+
+func (d *datum) UnmarshalJSON(b []byte) error {
+	type plain datum
+	return jsonext.Unmarshal(b, (*plain)(d), &d.ext)
+}
+
+func (d datum) MarshalJSON() ([]byte, error) {
+	type plain datum
+	return jsonext.Marshal(plain(d), d.ext)
 }
 
 type cjError struct {
 	TTitle  string `json:"title,omitempty"`
 	Code    string `json:"code,omitempty"`
 	Message string `json:"message,omitempty"`
+
+	ext jsonext.Extensions
+}
+
+func (e *cjError) UnmarshalJSON(b []byte) error {
+	type plain cjError
+	return jsonext.Unmarshal(b, (*plain)(e), &e.ext)
+}
+
+func (e cjError) MarshalJSON() ([]byte, error) {
+	type plain cjError
+	return jsonext.Marshal(plain(e), e.ext)
 }
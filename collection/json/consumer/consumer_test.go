@@ -26,3 +26,77 @@ func TestCollection(t *testing.T) {
 		t.Errorf("Wanted %+v, got %+v", want, got)
 	}
 }
+
+func TestCollectionMarshal(t *testing.T) {
+	// Should be able to marshal a collection into an equivalent document
+	doc := `{"collection":{
+		"version":"1.0",
+		"href":"http://example.com/friends/",
+		"ext":{"foo":"bar"},
+		"links":[{"href":"http://example.com/rss","rel":"feed","ext":1}],
+		"items":[{
+			"href":"http://example.com/friends/jdoe",
+			"data":[
+				{"name":"full-name","value":"J. Doe","prompt":"Full Name"},
+				{"name":"age","value":42},
+				{"name":"active","value":true,"ext":[1,2]},
+				{"name":"nickname","value":null}
+			],
+			"links":[{"href":"http://example.com/blogs/jdoe","rel":"blog","prompt":"Blog"}]
+		}],
+		"queries":[{"href":"http://example.com/search","rel":"search","prompt":"Search","data":[{"name":"search","value":""}]}]
+	}}`
+	c, err := NewCollection([]byte(doc))
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Errorf("Unexpected error from json.Marshal: %v", err)
+	}
+	var want, got interface{}
+	json.Unmarshal([]byte(doc), &want)
+	json.Unmarshal(b, &got)
+	if !reflect.DeepEqual(want, got) {
+		t.Error("Should be able to marshal a collection into an equivalent document")
+		t.Errorf("Wanted %s, got %s", doc, b)
+	}
+
+	// Should keep empty arrays and strings
+	for _, doc := range []string{
+		`{"collection":{"version":"1.0","href":"","items":[],"template":{"data":[]}}}`,
+		`{"collection":{"version":"1.0","links":[],"queries":[{"href":"","rel":"search","prompt":"","data":[]}]}}`,
+		`{"collection":{"items":[{"href":"","data":[{"name":"","value":"","prompt":""}],"links":[]}],"template":null}}`,
+		`{"collection":{"error":{"title":"","code":"","message":""},"errors":[]}}`,
+	} {
+		c, err = NewCollection([]byte(doc))
+		if err != nil {
+			t.Errorf("Unexpected error from NewCollection: %v", err)
+		}
+		b, err = json.Marshal(c)
+		if err != nil {
+			t.Errorf("Unexpected error from json.Marshal: %v", err)
+		}
+		var want, got interface{}
+		json.Unmarshal([]byte(doc), &want)
+		json.Unmarshal(b, &got)
+		if !reflect.DeepEqual(want, got) {
+			t.Error("Should keep empty arrays and strings")
+			t.Errorf("Wanted %s, got %s", doc, b)
+		}
+	}
+
+	// Should not add empty template or error objects
+	c, err = NewCollection([]byte(`{"collection":{"version":"1.0"}}`))
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	b, err = json.Marshal(c)
+	if err != nil {
+		t.Errorf("Unexpected error from json.Marshal: %v", err)
+	}
+	if string(b) != `{"collection":{"version":"1.0"}}` {
+		t.Error("Should not add empty template or error objects")
+		t.Errorf("Wanted %s, got %s", `{"collection":{"version":"1.0"}}`, b)
+	}
+}
@@ -0,0 +1,93 @@
+package consumer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/skriptble/hyper/collection/json/internal/jsonext"
+)
+
+// Error is the error described by a Collection+JSON document, combining its
+// error object with the errors extension. Fields maps the name of each datum
+// listed in the errors extension to its messages, allowing a UI to highlight
+// the inputs of a template that need fixing.
+type Error struct {
+	Title   string
+	Code    string
+	Message string
+	Fields  map[string][]string
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Title
+	}
+	if msg == "" {
+		msg = e.Code
+	}
+	if len(e.Fields) == 0 {
+		return "consumer: " + msg
+	}
+
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fields := make([]string, len(names))
+	for i, name := range names {
+		fields[i] = name + ": " + strings.Join(e.Fields[name], ", ")
+	}
+	if msg == "" {
+		return "consumer: " + strings.Join(fields, "; ")
+	}
+	return "consumer: " + msg + " (" + strings.Join(fields, "; ") + ")"
+}
+
+// cjFieldError is an entry of the errors extension.
+type cjFieldError struct {
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	ext jsonext.Extensions
+}
+
+func (e *cjFieldError) UnmarshalJSON(b []byte) error {
+	type plain cjFieldError
+	return jsonext.Unmarshal(b, (*plain)(e), &e.ext)
+}
+
+func (e cjFieldError) MarshalJSON() ([]byte, error) {
+	type plain cjFieldError
+	return jsonext.Marshal(plain(e), e.ext)
+}
+
+// Err returns the error described by the collection, or nil if there is none.
+// The message of an entry of the errors extension falls back to its title and
+// then its code.
+func (c collection) Err() error {
+	if c.Error == nil && len(c.Errors) == 0 {
+		return nil
+	}
+	e := new(Error)
+	if c.Error != nil {
+		e.Title, e.Code, e.Message = c.Error.TTitle, c.Error.Code, c.Error.Message
+	}
+	if len(c.Errors) > 0 {
+		e.Fields = make(map[string][]string)
+	}
+	for _, fe := range c.Errors {
+		msg := fe.Message
+		if msg == "" {
+			msg = fe.Title
+		}
+		if msg == "" {
+			msg = fe.Code
+		}
+		e.Fields[fe.Name] = append(e.Fields[fe.Name], msg)
+	}
+	return e
+}
@@ -0,0 +1,67 @@
+package consumer
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestErr(t *testing.T) {
+	// Should return nil when the document describes no error
+	c, err := NewCollection([]byte(`{"collection":{"version":"1.0"}}`))
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	if err := c.Err(); err != nil {
+		t.Error("Should return nil when the document describes no error")
+		t.Errorf("Wanted %v, got %v", nil, err)
+	}
+
+	// Should map the errors extension to field names
+	doc := `{"collection":{"version":"1.0",
+		"error":{"title":"Bad Request","code":"400","message":"One or more fields are invalid."},
+		"errors":[
+			{"name":"email","message":"must contain an @"},
+			{"name":"email","title":"Taken"},
+			{"name":"age","code":"range","ext":true}
+		]}}`
+	c, err = NewCollection([]byte(doc))
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	var e *Error
+	if !errors.As(c.Err(), &e) {
+		t.Fatalf("Should return an *Error, got %T", c.Err())
+	}
+	want := &Error{
+		Title:   "Bad Request",
+		Code:    "400",
+		Message: "One or more fields are invalid.",
+		Fields: map[string][]string{
+			"email": {"must contain an @", "Taken"},
+			"age":   {"range"},
+		},
+	}
+	if !reflect.DeepEqual(want, e) {
+		t.Error("Should map the errors extension to field names")
+		t.Errorf("Wanted %+v, got %+v", want, e)
+	}
+	wantMsg := "consumer: One or more fields are invalid. (age: range; email: must contain an @, Taken)"
+	if e.Error() != wantMsg {
+		t.Errorf("Wanted %v, got %v", wantMsg, e.Error())
+	}
+
+	// Should keep the errors extension when marshaling
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Errorf("Unexpected error from json.Marshal: %v", err)
+	}
+	var wantDoc, gotDoc interface{}
+	json.Unmarshal([]byte(doc), &wantDoc)
+	json.Unmarshal(b, &gotDoc)
+	if !reflect.DeepEqual(wantDoc, gotDoc) {
+		t.Error("Should keep the errors extension when marshaling")
+		t.Errorf("Wanted %s, got %s", doc, b)
+	}
+}
@@ -0,0 +1,159 @@
+package consumer
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/skriptble/hyper/collection/json"
+)
+
+// ErrTypeUnknown is returned when an option is passed into a New function for a
+// type that does match the types it knows how to configure.
+var ErrTypeUnknown = errors.New("consumer: option given as argument for mismatching type")
+
+// Option is a configuration option that can be passed into New functions.
+// Options are safe to reuse in multiple invocations of New functions. If the
+// Option is passed into a New function for a type it does not support it will
+// return an ErrTypeUnknown error.
+type Option func(interface{}) error
+
+// Fetcher retrieves Collection+JSON documents over HTTP. Every request made by
+// a Fetcher, whether it is traversing a link or submitting a template, goes
+// through the same checks so that hrefs supplied by a server cannot be used to
+// reach hosts the client did not intend to reach.
+//
+// A Fetcher is safe for concurrent use.
+type Fetcher struct {
+	fetcher *fetcher
+}
+
+type fetcher struct {
+	client  *http.Client
+	policy  Policy
+	timeout time.Duration
+	auth    map[string]Authenticator
+	limits  map[string]Limit
+	clock   Clock
+}
+
+// DefaultTimeout is the time limit of a request made by a Fetcher when no
+// timeout is given.
+const DefaultTimeout = 30 * time.Second
+
+// NewFetcher creates a Fetcher configured by the given options. If no Policy
+// is given, DefaultPolicy is used, if no timeout is given, DefaultTimeout is
+// used.
+func NewFetcher(opts ...Option) (Fetcher, error) {
+	f := new(fetcher)
+	f.policy = DefaultPolicy()
+	f.timeout = DefaultTimeout
+	f.clock = realClock{}
+	for _, opt := range opts {
+		err := opt(f)
+		if err != nil {
+			return Fetcher{}, err
+		}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   f.policy.control,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be the address dialed, which would bypass the address
+	// checks of the policy.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	var rt http.RoundTripper = transport
+	if len(f.limits) > 0 {
+		rt = newLimitTransport(rt, f.limits, f.clock)
+	}
+	if len(f.auth) > 0 {
+		rt = authTransport{base: rt, auth: f.auth}
+	}
+	f.client = &http.Client{
+		Transport:     rt,
+		CheckRedirect: f.checkRedirect,
+		Timeout:       f.timeout,
+	}
+
+	return Fetcher{f}, nil
+}
+
+// NewTimeout creates an Option that sets the time limit of a request made by
+// a Fetcher, including following its redirects and reading the response body.
+// A timeout of zero means there is no time limit.
+func NewTimeout(d time.Duration) Option {
+	return func(i interface{}) error {
+		f, ok := i.(*fetcher)
+		if !ok {
+			return ErrTypeUnknown
+		}
+		f.timeout = d
+		return nil
+	}
+}
+
+// Get retrieves the Collection at href.
+func (f Fetcher) Get(href string) (Collection, error) {
+	req, err := http.NewRequest(http.MethodGet, href, nil)
+	if err != nil {
+		return nil, err
+	}
+	return f.Do(req)
+}
+
+// Do sends req and converts the response body into a Collection. The Accept
+// header is set to the Collection+JSON media type if it is not already set.
+func (f Fetcher) Do(req *http.Request) (Collection, error) {
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", cj.MediaType)
+	}
+	b, err := f.fetcher.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return NewCollection(b)
+}
+
+// do is the single place requests are sent from, it enforces the policy of
+// the fetcher and returns the response body.
+func (f *fetcher) do(req *http.Request) ([]byte, error) {
+	err := f.policy.checkURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	limit := f.policy.MaxBytes
+	if limit <= 0 {
+		return io.ReadAll(resp.Body)
+	}
+	if resp.ContentLength > limit {
+		return nil, ErrResponseTooLarge
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > limit {
+		return nil, ErrResponseTooLarge
+	}
+	return b, nil
+}
+
+func (f *fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > f.policy.MaxRedirects {
+		return ErrTooManyRedirects
+	}
+	return f.policy.checkURL(req.URL)
+}
@@ -0,0 +1,135 @@
+package consumer
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetcher(t *testing.T) {
+	doc := `{"collection":{"version":"1.0","href":"http://example.com"}}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(doc))
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat(" ", 64) + doc))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/", http.StatusFound)
+	})
+	mux.HandleFunc("/escape", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://example.org/", http.StatusFound)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// Should not be able to connect to loopback addresses by default
+	f, err := NewFetcher()
+	if err != nil {
+		t.Errorf("Unexpected error from NewFetcher: %v", err)
+	}
+	_, err = f.Get(srv.URL)
+	if !errors.Is(err, ErrAddressDenied) {
+		t.Error("Should not be able to connect to loopback addresses by default")
+		t.Errorf("Wanted %v, got %v", ErrAddressDenied, err)
+	}
+
+	p := DefaultPolicy()
+	p.AllowPrivate = true
+	p.MaxBytes = int64(len(doc))
+	p.MaxRedirects = 1
+	f, err = NewFetcher(NewPolicy(p))
+	if err != nil {
+		t.Errorf("Unexpected error from NewFetcher: %v", err)
+	}
+
+	// Should be able to fetch a collection
+	_, err = f.Get(srv.URL)
+	if err != nil {
+		t.Error("Should be able to fetch a collection")
+		t.Errorf("Wanted nil, got %v", err)
+	}
+
+	// Should be able to follow redirects within the limit
+	_, err = f.Get(srv.URL + "/redirect")
+	if err != nil {
+		t.Error("Should be able to follow redirects within the limit")
+		t.Errorf("Wanted nil, got %v", err)
+	}
+
+	// Should check redirects against the policy
+	p.DenyHosts = []string{"example.org"}
+	f, err = NewFetcher(NewPolicy(p))
+	if err != nil {
+		t.Errorf("Unexpected error from NewFetcher: %v", err)
+	}
+	_, err = f.Get(srv.URL + "/escape")
+	if !errors.Is(err, ErrHostDenied) {
+		t.Error("Should check redirects against the policy")
+		t.Errorf("Wanted %v, got %v", ErrHostDenied, err)
+	}
+
+	// Should not be able to exceed the redirect limit
+	p.MaxRedirects = 0
+	f, err = NewFetcher(NewPolicy(p))
+	if err != nil {
+		t.Errorf("Unexpected error from NewFetcher: %v", err)
+	}
+	_, err = f.Get(srv.URL + "/redirect")
+	if !errors.Is(err, ErrTooManyRedirects) {
+		t.Error("Should not be able to exceed the redirect limit")
+		t.Errorf("Wanted %v, got %v", ErrTooManyRedirects, err)
+	}
+
+	// Should not be able to exceed the maximum response size
+	_, err = f.Get(srv.URL + "/large")
+	if err != ErrResponseTooLarge {
+		t.Error("Should not be able to exceed the maximum response size")
+		t.Errorf("Wanted %v, got %v", ErrResponseTooLarge, err)
+	}
+
+	// Should give up on requests that exceed the timeout
+	f, err = NewFetcher(NewPolicy(p), NewTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Errorf("Unexpected error from NewFetcher: %v", err)
+	}
+	_, err = f.Get(srv.URL + "/slow")
+	var nerr net.Error
+	if !errors.As(err, &nerr) || !nerr.Timeout() {
+		t.Error("Should give up on requests that exceed the timeout")
+		t.Errorf("Wanted a timeout, got %v", err)
+	}
+	if f.fetcher.client.Timeout != 50*time.Millisecond {
+		t.Errorf("Wanted %v, got %v", 50*time.Millisecond, f.fetcher.client.Timeout)
+	}
+	f, _ = NewFetcher()
+	if f.fetcher.client.Timeout != DefaultTimeout {
+		t.Error("Should use the default timeout")
+		t.Errorf("Wanted %v, got %v", DefaultTimeout, f.fetcher.client.Timeout)
+	}
+
+	// Should not share the policy with its caller
+	p = DefaultPolicy()
+	f, _ = NewFetcher(NewPolicy(p))
+	p.Schemes[0] = "ftp"
+	DefaultPolicy().Schemes[1] = "ftp"
+	if f.fetcher.policy.Schemes[0] != "http" || DefaultPolicy().Schemes[1] != "https" {
+		t.Error("Should not share the policy with its caller")
+		t.Errorf("Got %v and %v", f.fetcher.policy.Schemes, DefaultPolicy().Schemes)
+	}
+
+	// Should not be able to pass an unknown option to NewFetcher
+	_, err = NewFetcher(func(interface{}) error { return ErrTypeUnknown })
+	if err != ErrTypeUnknown {
+		t.Error("Should not be able to pass an unknown option to NewFetcher")
+		t.Errorf("Wanted %v, got %v", ErrTypeUnknown, err)
+	}
+}
@@ -0,0 +1,195 @@
+package consumer
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit configures how politely a Fetcher treats a host. Requests to the host
+// are rate limited using a token bucket and the number of requests in flight to
+// a single origin can be capped.
+type Limit struct {
+	// Rate is the number of requests per second that can be made to the
+	// host. A value of zero or less means requests are not rate limited.
+	Rate float64
+	// Burst is the number of requests that can be made at once before the
+	// rate applies. A value less than one is treated as one.
+	Burst int
+	// MaxInFlight is the maximum number of requests to a single origin that
+	// can be waiting on a response at the same time. A request is in flight
+	// until its response body is closed. A value of zero or less means there
+	// is no maximum.
+	MaxInFlight int
+}
+
+// Clock provides the current time and a way to wait. It can be replaced using
+// NewClock, which is mostly useful for tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// NewRateLimit creates an Option that sets the Limit for requests made by a
+// Fetcher to host. If host is empty the Limit is used for every host that
+// does not have a Limit of its own.
+func NewRateLimit(host string, l Limit) Option {
+	host = strings.ToLower(host)
+	return func(i interface{}) error {
+		f, ok := i.(*fetcher)
+		if !ok {
+			return ErrTypeUnknown
+		}
+		if f.limits == nil {
+			f.limits = make(map[string]Limit)
+		}
+		f.limits[host] = l
+		return nil
+	}
+}
+
+// NewClock creates an Option that sets the Clock used by a Fetcher for rate
+// limiting.
+func NewClock(c Clock) Option {
+	return func(i interface{}) error {
+		f, ok := i.(*fetcher)
+		if !ok {
+			return ErrTypeUnknown
+		}
+		f.clock = c
+		return nil
+	}
+}
+
+// bucket is a token bucket for a single host.
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token from the bucket and returns how long the caller must
+// wait before the token can be used. Tokens can go negative, which queues the
+// callers behind each other.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.last.IsZero() {
+		b.tokens = b.burst
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// limitTransport enforces the Limits of a Fetcher on each request, including
+// each redirect and retry.
+type limitTransport struct {
+	base   http.RoundTripper
+	limits map[string]Limit
+	clock  Clock
+
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	inFlight map[string]chan struct{}
+}
+
+func newLimitTransport(base http.RoundTripper, limits map[string]Limit, clock Clock) *limitTransport {
+	return &limitTransport{
+		base:     base,
+		limits:   limits,
+		clock:    clock,
+		buckets:  make(map[string]*bucket),
+		inFlight: make(map[string]chan struct{}),
+	}
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := strings.ToLower(req.URL.Hostname())
+	l, ok := t.limits[host]
+	if !ok {
+		l, ok = t.limits[""]
+	}
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	b, sem := t.get(host, origin(req.URL), l)
+	if b != nil {
+		if wait := b.reserve(t.clock.Now()); wait > 0 {
+			select {
+			case <-t.clock.After(wait):
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			}
+		}
+	}
+	if sem == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	select {
+	case sem <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		<-sem
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, sem: sem}
+	return resp, nil
+}
+
+// get returns the bucket for host and the in flight semaphore for o, creating
+// them if necessary. Either is nil if l does not limit it.
+func (t *limitTransport) get(host, o string, l Limit) (*bucket, chan struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b, ok := t.buckets[host]
+	if !ok && l.Rate > 0 {
+		burst := l.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		b = &bucket{rate: l.Rate, burst: float64(burst)}
+		t.buckets[host] = b
+	}
+	sem, ok := t.inFlight[o]
+	if !ok && l.MaxInFlight > 0 {
+		sem = make(chan struct{}, l.MaxInFlight)
+		t.inFlight[o] = sem
+	}
+	return b, sem
+}
+
+// releaseBody releases an in flight slot when the response body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	sem  chan struct{}
+	once sync.Once
+}
+
+func (rb *releaseBody) Close() error {
+	err := rb.ReadCloser.Close()
+	rb.once.Do(func() { <-rb.sem })
+	return err
+}
@@ -0,0 +1,132 @@
+package consumer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"collection":{"version":"1.0"}}`))
+	}))
+	defer srv.Close()
+
+	p := DefaultPolicy()
+	p.AllowPrivate = true
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	f, err := NewFetcher(
+		NewPolicy(p),
+		NewClock(clock),
+		NewRateLimit("", Limit{Rate: 100}),
+		NewRateLimit("127.0.0.1", Limit{Rate: 2, Burst: 2}),
+	)
+	if err != nil {
+		t.Errorf("Unexpected error from NewFetcher: %v", err)
+	}
+
+	// Should wait once the burst has been used
+	for i := 0; i < 4; i++ {
+		_, err = f.Get(srv.URL)
+		if err != nil {
+			t.Errorf("Unexpected error from Get: %v", err)
+		}
+	}
+	want := []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}
+	if !reflect.DeepEqual(want, clock.waits) {
+		t.Error("Should wait once the burst has been used")
+		t.Errorf("Wanted %v, got %v", want, clock.waits)
+	}
+
+	// Should refill the bucket as time passes
+	clock.waits = nil
+	clock.now = clock.now.Add(time.Second)
+	_, err = f.Get(srv.URL)
+	if err != nil {
+		t.Errorf("Unexpected error from Get: %v", err)
+	}
+	if len(clock.waits) != 0 {
+		t.Error("Should refill the bucket as time passes")
+		t.Errorf("Wanted no waits, got %v", clock.waits)
+	}
+
+	// Should not be able to attach a rate limit to an unknown type
+	err = NewRateLimit("", Limit{})(struct{}{})
+	if err != ErrTypeUnknown {
+		t.Error("Should not be able to attach a rate limit to an unknown type")
+		t.Errorf("Wanted %v, got %v", ErrTypeUnknown, err)
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.Write([]byte(`{"collection":{"version":"1.0"}}`))
+	}))
+	defer srv.Close()
+
+	p := DefaultPolicy()
+	p.AllowPrivate = true
+	f, err := NewFetcher(NewPolicy(p), NewRateLimit("", Limit{MaxInFlight: 1}))
+	if err != nil {
+		t.Errorf("Unexpected error from NewFetcher: %v", err)
+	}
+
+	// Should not have more requests in flight than the maximum
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := f.Get(srv.URL)
+			if err != nil {
+				t.Errorf("Unexpected error from Get: %v", err)
+			}
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		release <- struct{}{}
+	}
+	wg.Wait()
+	if peak != 1 {
+		t.Error("Should not have more requests in flight than the maximum")
+		t.Errorf("Wanted 1, got %d", peak)
+	}
+}
@@ -0,0 +1,208 @@
+package consumer
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrSchemeDenied is returned when a request is made to an href whose scheme
+// is not allowed by the Policy.
+var ErrSchemeDenied = errors.New("consumer: href scheme is not allowed by policy")
+
+// ErrHostDenied is returned when a request is made to a host that is either
+// denied or not allowed by the Policy.
+var ErrHostDenied = errors.New("consumer: href host is not allowed by policy")
+
+// ErrCrossOrigin is returned when the Policy is restricted to a single origin
+// and a request is made to a different origin.
+var ErrCrossOrigin = errors.New("consumer: href is not same origin")
+
+// ErrAddressDenied is returned when a host resolves to a loopback, private,
+// shared, link-local, or unspecified IP address and the Policy does not allow
+// them.
+var ErrAddressDenied = errors.New("consumer: address is not allowed by policy")
+
+// ErrResponseTooLarge is returned when a response body is larger than the
+// maximum size allowed by the Policy.
+var ErrResponseTooLarge = errors.New("consumer: response body exceeds maximum size")
+
+// ErrTooManyRedirects is returned when a request is redirected more times than
+// the Policy allows.
+var ErrTooManyRedirects = errors.New("consumer: too many redirects")
+
+// Policy restricts which hrefs a Fetcher will follow. Since the hrefs in a
+// Collection+JSON document are supplied by the server, every request made by a
+// Fetcher, including each redirect, is checked against its Policy.
+//
+// The zero value of Policy allows no schemes and therefore denies every
+// request, the Policy returned by DefaultPolicy should be used as a starting
+// point instead.
+type Policy struct {
+	// Schemes is the list of allowed URL schemes.
+	Schemes []string
+	// AllowHosts, if not empty, is the list of hosts requests may be made
+	// to. A host matches an entry if it is equal to the entry or is a
+	// subdomain of it.
+	AllowHosts []string
+	// DenyHosts is the list of hosts requests may not be made to. It is
+	// matched the same way as AllowHosts and takes precedence over it.
+	DenyHosts []string
+	// Origin, if not nil, restricts requests to the scheme, host, and port
+	// of this URL.
+	Origin *url.URL
+	// AllowPrivate allows connections to loopback, private, shared
+	// (100.64.0.0/10), link-local, and unspecified (0.0.0.0/8) IP addresses.
+	// These are checked at dial time, after the host has been resolved.
+	AllowPrivate bool
+	// MaxBytes is the maximum size of a response body. A value of zero or
+	// less means there is no maximum.
+	MaxBytes int64
+	// MaxRedirects is the maximum number of redirects that will be
+	// followed. A value of zero disables following redirects.
+	MaxRedirects int
+}
+
+// DefaultPolicy returns the Policy used by a Fetcher when none is given. It
+// allows http and https hrefs to public addresses, limits response bodies to
+// 10MB, and follows up to 10 redirects. Each call returns a new Policy, so
+// changing it does not change the Policy of other Fetchers.
+func DefaultPolicy() Policy {
+	return Policy{
+		Schemes:      []string{"http", "https"},
+		MaxBytes:     10 << 20,
+		MaxRedirects: 10,
+	}
+}
+
+// NewPolicy creates an Option that sets the Policy of a Fetcher. The Fetcher
+// keeps a copy of p, changing p afterwards does not change its Policy.
+func NewPolicy(p Policy) Option {
+	return func(i interface{}) error {
+		f, ok := i.(*fetcher)
+		if !ok {
+			return ErrTypeUnknown
+		}
+		f.policy = p.clone()
+		return nil
+	}
+}
+
+// clone returns a copy of p that shares no memory with it.
+func (p Policy) clone() Policy {
+	p.Schemes = append([]string(nil), p.Schemes...)
+	p.AllowHosts = append([]string(nil), p.AllowHosts...)
+	p.DenyHosts = append([]string(nil), p.DenyHosts...)
+	if p.Origin != nil {
+		origin := *p.Origin
+		p.Origin = &origin
+	}
+	return p
+}
+
+// checkURL checks that a request may be made to u.
+func (p Policy) checkURL(u *url.URL) error {
+	scheme := strings.ToLower(u.Scheme)
+	if !contains(p.Schemes, scheme) {
+		return ErrSchemeDenied
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if p.Origin != nil && origin(u) != origin(p.Origin) {
+		return ErrCrossOrigin
+	}
+	for _, deny := range p.DenyHosts {
+		if matchHost(host, deny) {
+			return ErrHostDenied
+		}
+	}
+	if len(p.AllowHosts) > 0 {
+		allowed := false
+		for _, allow := range p.AllowHosts {
+			if matchHost(host, allow) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ErrHostDenied
+		}
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return p.checkIP(ip)
+	}
+	return nil
+}
+
+// deniedNets holds the ranges denied along with those reported by the methods
+// of net.IP: "this network", which some systems route to the local host, and
+// the shared address space used by carrier-grade NAT.
+var deniedNets = []*net.IPNet{
+	{IP: net.IPv4(0, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)},
+}
+
+// checkIP checks that a connection may be made to ip.
+func (p Policy) checkIP(ip net.IP) error {
+	if p.AllowPrivate {
+		return nil
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() {
+		return ErrAddressDenied
+	}
+	for _, n := range deniedNets {
+		if n.Contains(ip) {
+			return ErrAddressDenied
+		}
+	}
+	return nil
+}
+
+// control is used as the Control function of the net.Dialer used by a
+// Fetcher, this ensures the address checked is the address connected to.
+func (p Policy) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ErrAddressDenied
+	}
+	return p.checkIP(ip)
+}
+
+// origin returns the scheme, host, and port of u, filling in the default port
+// for the scheme if none is present.
+func origin(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	port := u.Port()
+	if port == "" {
+		switch scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+	return scheme + "://" + net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+}
+
+func matchHost(host, pattern string) bool {
+	pattern = strings.ToLower(strings.TrimPrefix(pattern, "."))
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.ToLower(v) == s {
+			return true
+		}
+	}
+	return false
+}
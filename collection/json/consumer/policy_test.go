@@ -0,0 +1,62 @@
+package consumer
+
+import (
+	"net"
+	"net/url"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	origin, _ := url.Parse("https://example.com")
+	p := Policy{
+		Schemes:    []string{"https"},
+		AllowHosts: []string{"example.com"},
+		DenyHosts:  []string{"admin.example.com"},
+	}
+	tests := []struct {
+		href   string
+		policy Policy
+		want   error
+	}{
+		{"https://example.com/foo", p, nil},
+		{"https://api.example.com/foo", p, nil},
+		{"http://example.com/foo", p, ErrSchemeDenied},
+		{"file:///etc/passwd", p, ErrSchemeDenied},
+		{"https://example.org/foo", p, ErrHostDenied},
+		{"https://admin.example.com/foo", p, ErrHostDenied},
+		{"https://notexample.com/foo", p, ErrHostDenied},
+		{"https://example.com:443/foo", Policy{Schemes: []string{"https"}, Origin: origin}, nil},
+		{"https://example.com:8443/foo", Policy{Schemes: []string{"https"}, Origin: origin}, ErrCrossOrigin},
+		{"https://api.example.com/foo", Policy{Schemes: []string{"https"}, Origin: origin}, ErrCrossOrigin},
+		{"http://127.0.0.1/foo", DefaultPolicy(), ErrAddressDenied},
+		{"http://[::1]/foo", DefaultPolicy(), ErrAddressDenied},
+		{"http://169.254.169.254/latest", DefaultPolicy(), ErrAddressDenied},
+		{"http://10.0.0.1/foo", DefaultPolicy(), ErrAddressDenied},
+		{"http://10.0.0.1/foo", Policy{Schemes: []string{"http"}, AllowPrivate: true}, nil},
+		{"http://0.0.0.0/foo", DefaultPolicy(), ErrAddressDenied},
+		{"http://0.1.2.3/foo", DefaultPolicy(), ErrAddressDenied},
+		{"http://100.64.0.1/foo", DefaultPolicy(), ErrAddressDenied},
+		{"http://100.127.255.254/foo", DefaultPolicy(), ErrAddressDenied},
+		{"http://[::ffff:100.64.0.1]/foo", DefaultPolicy(), ErrAddressDenied},
+		{"http://100.64.0.1/foo", Policy{Schemes: []string{"http"}, AllowPrivate: true}, nil},
+		{"http://100.128.0.1/foo", DefaultPolicy(), nil},
+		{"http://93.184.216.34/foo", DefaultPolicy(), nil},
+	}
+	for _, test := range tests {
+		u, err := url.Parse(test.href)
+		if err != nil {
+			t.Errorf("Unexpected error from url.Parse: %v", err)
+		}
+		got := test.policy.checkURL(u)
+		if got != test.want {
+			t.Errorf("Checking %s: Wanted %v, got %v", test.href, test.want, got)
+		}
+	}
+
+	// Should deny private addresses at dial time
+	err := DefaultPolicy().control("tcp", net.JoinHostPort("192.168.1.1", "80"), nil)
+	if err != ErrAddressDenied {
+		t.Error("Should deny private addresses at dial time")
+		t.Errorf("Wanted %v, got %v", ErrAddressDenied, err)
+	}
+}
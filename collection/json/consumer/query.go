@@ -1,6 +1,13 @@
 package consumer
 
-import "strings"
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/skriptble/hyper/uritemplate"
+)
 
 // Query represents a Collection+JSON query.
 type Query interface {
@@ -10,6 +17,7 @@ type Query interface {
 	Add(key string, value string) Query
 	// Prompt returns the prompt value from the query
 	Prompt() string
+	// URI returns the URI to request the query with its current values.
 	URI() string
 }
 
@@ -42,15 +50,115 @@ func (c collection) Query(rels ...string) []Query {
 	return queries
 }
 
+// Add returns a copy of the query with a datum named key added to its data,
+// keeping any data of the same name.
 func (q query) Add(key string, value string) Query {
-	return nil
+	data := make([]datum, len(q.Data), len(q.Data)+1)
+	copy(data, q.Data)
+	q.Data = append(data, datum{Name: key, Value: stringValue(value)})
+	return q
 }
+
+// Set returns a copy of the query with the value of the datum named key set to
+// value. Any other data of the same name are removed. If the query has no
+// datum named key one is added.
 func (q query) Set(key string, value string) Query {
-	return nil
+	data := make([]datum, 0, len(q.Data)+1)
+	set := false
+	for _, d := range q.Data {
+		if d.Name != key {
+			data = append(data, d)
+			continue
+		}
+		if set {
+			continue
+		}
+		d.Value = stringValue(value)
+		data = append(data, d)
+		set = true
+	}
+	if !set {
+		data = append(data, datum{Name: key, Value: stringValue(value)})
+	}
+	q.Data = data
+	return q
 }
+
+// Prompt returns the prompt of the query.
 func (q query) Prompt() string {
-	return ""
+	return q.PromptStr
 }
+
+// URI returns the URI to request the query with the values of its data. If the
+// href of the query is an RFC 6570 URI Template it is expanded using the data
+// as its variables, where data sharing a name form a list. Otherwise the data
+// are added to the query string of the href, replacing any parameters of the
+// same name. Data without a value are left out. URI returns an empty string if
+// the href cannot be parsed.
 func (q query) URI() string {
-	return ""
+	names, values := q.values()
+	if strings.Contains(q.Href, "{") {
+		vars := make(map[string]interface{}, len(values))
+		for name, vals := range values {
+			if len(vals) == 1 {
+				vars[name] = vals[0]
+			} else {
+				vars[name] = vals
+			}
+		}
+		uri, err := uritemplate.Expand(q.Href, vars)
+		if err != nil {
+			return ""
+		}
+		return uri
+	}
+
+	u, err := url.Parse(q.Href)
+	if err != nil {
+		return ""
+	}
+	params := u.Query()
+	for _, name := range names {
+		params[name] = values[name]
+	}
+	u.RawQuery = params.Encode()
+	return u.String()
+}
+
+// values returns the values of the data of the query by name, along with the
+// names in the order they first appear.
+func (q query) values() ([]string, map[string][]string) {
+	var names []string
+	values := make(map[string][]string)
+	for _, d := range q.Data {
+		val, ok := d.value()
+		if !ok {
+			continue
+		}
+		if _, ok := values[d.Name]; !ok {
+			names = append(names, d.Name)
+		}
+		values[d.Name] = append(values[d.Name], val)
+	}
+	return names, values
+}
+
+// value returns the value of the datum as a string. JSON strings are unquoted,
+// other values are returned as they appear in the document. It returns false if
+// the datum has no value or its value is null.
+func (d datum) value() (string, bool) {
+	raw := bytes.TrimSpace(d.Value)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return "", false
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s, true
+	}
+	return string(raw), true
+}
+
+func stringValue(s string) json.RawMessage {
+	b, _ := json.Marshal(s)
+	return b
 }
@@ -0,0 +1,67 @@
+package consumer
+
+import "testing"
+
+func TestQuery(t *testing.T) {
+	doc := `{"collection":{"version":"1.0","queries":[
+		{"href":"http://example.com/search?page=2","rel":"search","name":"people","prompt":"Search",
+			"data":[{"name":"q","value":""},{"name":"page","value":1},{"name":"sort","value":null}]},
+		{"href":"http://example.com/orders/{id}{?fields*}","rel":"order","name":"order",
+			"data":[{"name":"id","value":"a/1"},{"name":"fields","value":"total"}]}
+	]}}`
+	c, err := NewCollection([]byte(doc))
+	if err != nil {
+		t.Fatalf("Unexpected error from NewCollection: %v", err)
+	}
+
+	search := c.Query("people")
+	if len(search) != 1 {
+		t.Fatalf("Wanted %v queries, got %v", 1, len(search))
+	}
+	// Should return the prompt of a query
+	if search[0].Prompt() != "Search" {
+		t.Error("Should return the prompt of a query")
+		t.Errorf("Wanted %v, got %v", "Search", search[0].Prompt())
+	}
+
+	// Should add the data of a query to its query string
+	want := "http://example.com/search?page=1&q="
+	if got := search[0].URI(); got != want {
+		t.Error("Should add the data of a query to its query string")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should be able to set and add values without changing the original query
+	q := search[0].Set("q", "J. Doe").Add("sort", "name").Add("sort", "age")
+	want = "http://example.com/search?page=1&q=J.+Doe&sort=name&sort=age"
+	if got := q.URI(); got != want {
+		t.Error("Should be able to set and add values")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+	want = "http://example.com/search?page=1&q=J.+Doe&sort=size"
+	if got := q.Set("sort", "size").URI(); got != want {
+		t.Error("Should replace every value when setting a value")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+	want = "http://example.com/search?page=1&q="
+	if got := search[0].URI(); got != want {
+		t.Error("Should not change the original query")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should expand a templated href with the data of a query
+	order := c.Query("order")
+	if len(order) != 1 {
+		t.Fatalf("Wanted %v queries, got %v", 1, len(order))
+	}
+	want = "http://example.com/orders/a%2F1?fields=total"
+	if got := order[0].URI(); got != want {
+		t.Error("Should expand a templated href with the data of a query")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+	want = "http://example.com/orders/a%2F1?fields=total&fields=status"
+	if got := order[0].Add("fields", "status").URI(); got != want {
+		t.Error("Should expand data sharing a name as a list")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+}
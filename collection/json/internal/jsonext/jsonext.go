@@ -0,0 +1,141 @@
+// Package jsonext marshals the objects of a Collection+JSON document without
+// losing the parts of the document that encoding/json would drop: properties
+// added by extensions and properties that are present but empty.
+package jsonext
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Extensions holds what is needed to marshal an object back into JSON without
+// losing any part of the document it was unmarshaled from. The zero value
+// holds nothing.
+type Extensions struct {
+	// props holds the properties of the object that are not defined by the
+	// Collection+JSON specification, such as those added by extensions.
+	props map[string]json.RawMessage
+	// empty holds the names of the properties that were present in the
+	// document but are empty, e.g. "" or [], which omitempty would drop.
+	empty []string
+}
+
+// Unmarshal unmarshals b into v and stores any properties that do not match a
+// field of v, and any properties that are present but empty, in ext. The type
+// of v should not implement json.Unmarshaler.
+func Unmarshal(b []byte, v interface{}, ext *Extensions) error {
+	return unmarshal(b, v, ext, false)
+}
+
+// UnmarshalNumbers is Unmarshal, except that numbers are held as json.Number.
+func UnmarshalNumbers(b []byte, v interface{}, ext *Extensions) error {
+	return unmarshal(b, v, ext, true)
+}
+
+func unmarshal(b []byte, v interface{}, ext *Extensions, useNumber bool) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if useNumber {
+		dec.UseNumber()
+	}
+	err := dec.Decode(v)
+	if err != nil {
+		return err
+	}
+	var props map[string]json.RawMessage
+	err = json.Unmarshal(b, &props)
+	if err != nil {
+		return err
+	}
+	// Marshaling v shows which of the properties omitempty would drop.
+	out, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var kept map[string]json.RawMessage
+	err = json.Unmarshal(out, &kept)
+	if err != nil {
+		return err
+	}
+	// encoding/json matches keys case insensitively, so must this.
+	known := knownKeys(reflect.TypeOf(v).Elem())
+	var empty []string
+	for prop := range props {
+		for key := range known {
+			if !strings.EqualFold(prop, key) {
+				continue
+			}
+			if _, ok := kept[key]; !ok {
+				empty = append(empty, key)
+			}
+			delete(props, prop)
+			break
+		}
+	}
+	if len(props) > 0 {
+		ext.props = props
+	}
+	ext.empty = empty
+	return nil
+}
+
+// Marshal marshals v into JSON and adds the properties in ext to it. The type
+// of v should not implement json.Marshaler.
+func Marshal(v interface{}, ext Extensions) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || (len(ext.props) == 0 && len(ext.empty) == 0) {
+		return b, err
+	}
+	var props map[string]json.RawMessage
+	err = json.Unmarshal(b, &props)
+	if err != nil {
+		return nil, err
+	}
+	known := knownKeys(reflect.TypeOf(v))
+	rv := reflect.ValueOf(v)
+	for _, key := range ext.empty {
+		if _, ok := props[key]; ok {
+			continue
+		}
+		val, err := json.Marshal(rv.Field(known[key]).Interface())
+		if err != nil {
+			return nil, err
+		}
+		props[key] = val
+	}
+	for key, val := range ext.props {
+		if _, ok := props[key]; !ok {
+			props[key] = val
+		}
+	}
+	return json.Marshal(props)
+}
+
+var keyCache sync.Map
+
+// knownKeys returns the JSON property names of the fields of t along with the
+// index of the field.
+func knownKeys(t reflect.Type) map[string]int {
+	if keys, ok := keyCache.Load(t); ok {
+		return keys.(map[string]int)
+	}
+	keys := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		keys[name] = i
+	}
+	keyCache.Store(t, keys)
+	return keys
+}
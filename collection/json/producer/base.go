@@ -0,0 +1,149 @@
+package producer
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NewBase creates an Option that rewrites the hrefs of a collection against
+// the public base URL of a service, e.g. when it is served behind a reverse
+// proxy. Every href of the collection, its links, items, and queries that has
+// no host, or whose host is one of hosts, is rewritten once all other options
+// have been applied.
+//
+// The path of base is prefixed to the path of each href. If relative is false
+// hrefs are made absolute using the scheme and host of base, otherwise they
+// are emitted as path-absolute references, e.g. "/v1/orders/1".
+func NewBase(base url.URL, relative bool, hosts ...string) Option {
+	return func(i interface{}) error {
+		c, ok := i.(*collection)
+		if !ok {
+			return ErrTypeUnknown
+		}
+		c.base = &rebaser{base: base, relative: relative, hosts: hosts}
+		return nil
+	}
+}
+
+// BaseFromRequest returns the public base URL of r. It is derived from the
+// Forwarded header if present, otherwise from the X-Forwarded-Proto,
+// X-Forwarded-Host, and X-Forwarded-Prefix headers, falling back to the scheme
+// and host of r itself. These headers can be set by clients, so this should
+// only be used behind a proxy that overwrites them.
+func BaseFromRequest(r *http.Request) url.URL {
+	base := url.URL{Scheme: "http", Host: r.Host}
+	if r.TLS != nil {
+		base.Scheme = "https"
+	}
+
+	if fwd := r.Header.Get("Forwarded"); fwd != "" {
+		// Only the first element is used, it was added by the proxy
+		// closest to the client.
+		first := strings.Split(fwd, ",")[0]
+		for _, pair := range strings.Split(first, ";") {
+			kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			val := strings.Trim(kv[1], `"`)
+			switch strings.ToLower(kv[0]) {
+			case "proto":
+				base.Scheme = strings.ToLower(val)
+			case "host":
+				base.Host = val
+			}
+		}
+	} else {
+		if proto := firstValue(r.Header.Get("X-Forwarded-Proto")); proto != "" {
+			base.Scheme = strings.ToLower(proto)
+		}
+		if host := firstValue(r.Header.Get("X-Forwarded-Host")); host != "" {
+			base.Host = host
+		}
+	}
+	base.Path = firstValue(r.Header.Get("X-Forwarded-Prefix"))
+	return base
+}
+
+func firstValue(header string) string {
+	return strings.TrimSpace(strings.Split(header, ",")[0])
+}
+
+// rebaser rewrites hrefs against a base URL.
+type rebaser struct {
+	base     url.URL
+	relative bool
+	hosts    []string
+}
+
+// rebase rewrites every href of the collection. The slices of the collection
+// are copied, as they can share their arrays with reused Options.
+func (rb *rebaser) rebase(c *collection) {
+	c.Href = rb.href(c.Href)
+	c.Links = rb.links(c.Links)
+	items := make([]item, len(c.Items))
+	for i, itm := range c.Items {
+		itm.Href = rb.href(itm.Href)
+		itm.Links = rb.links(itm.Links)
+		items[i] = itm
+	}
+	queries := make([]query, len(c.Queries))
+	for i, q := range c.Queries {
+		q.Href = rb.href(q.Href)
+		queries[i] = q
+	}
+	if c.Items != nil {
+		c.Items = items
+	}
+	if c.Queries != nil {
+		c.Queries = queries
+	}
+}
+
+func (rb *rebaser) links(links []link) []link {
+	if links == nil {
+		return nil
+	}
+	rebased := make([]link, len(links))
+	for i, l := range links {
+		l.Href = rb.href(l.Href)
+		rebased[i] = l
+	}
+	return rebased
+}
+
+func (rb *rebaser) href(href string) string {
+	if href == "" {
+		return href
+	}
+	u, err := url.Parse(href)
+	if err != nil || !rb.internal(u) {
+		return href
+	}
+
+	p := u.Path
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	u.Path = strings.TrimSuffix(rb.base.Path, "/") + p
+	u.RawPath = ""
+	u.Scheme, u.Host, u.User = rb.base.Scheme, rb.base.Host, nil
+	if rb.relative {
+		u.Scheme, u.Host = "", ""
+	}
+	return u.String()
+}
+
+// internal reports whether u refers to the service itself.
+func (rb *rebaser) internal(u *url.URL) bool {
+	if u.Host == "" {
+		return u.Scheme == ""
+	}
+	for _, host := range rb.hosts {
+		if strings.EqualFold(u.Host, host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package producer
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestBase(t *testing.T) {
+	base := url.URL{Scheme: "https", Host: "api.example.com", Path: "/v1/"}
+	internal := url.URL{Scheme: "http", Host: "orders:8080", Path: "/orders/1"}
+	external := url.URL{Scheme: "http", Host: "example.org", Path: "/help"}
+	itemOpt, err := NewItem(url.URL{Path: "/orders/1"}, NewLink(internal, "self", "", "", ""))
+	if err != nil {
+		t.Errorf("Unexpected error from NewItem: %v", err)
+	}
+	queryOpt, err := NewQuery(url.URL{Path: "/orders/", RawQuery: "status=open"}, "search", "", "")
+	if err != nil {
+		t.Errorf("Unexpected error from NewQuery: %v", err)
+	}
+	opts := []Option{NewLink(external, "help", "", "", ""), itemOpt, queryOpt}
+
+	// Should make internal hrefs absolute against the base
+	c, err := NewCollection(append(opts, NewBase(base, false, "orders:8080"))...)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	want := []string{
+		"http://example.org/help",
+		"https://api.example.com/v1/orders/1",
+		"https://api.example.com/v1/orders/1",
+		"https://api.example.com/v1/orders/?status=open",
+	}
+	got := []string{
+		c.collection.Links[0].Href,
+		c.collection.Items[0].Href,
+		c.collection.Items[0].Links[0].Href,
+		c.collection.Queries[0].Href,
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Error("Should make internal hrefs absolute against the base")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should make internal hrefs relative to the base
+	c, err = NewCollection(append(opts, NewBase(base, true, "orders:8080"))...)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	want = []string{
+		"http://example.org/help",
+		"/v1/orders/1",
+		"/v1/orders/1",
+		"/v1/orders/?status=open",
+	}
+	got = []string{
+		c.collection.Links[0].Href,
+		c.collection.Items[0].Href,
+		c.collection.Items[0].Links[0].Href,
+		c.collection.Queries[0].Href,
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Error("Should make internal hrefs relative to the base")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should not change the hrefs of reused options
+	c, err = NewCollection(opts...)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	if c.collection.Items[0].Links[0].Href != "http://orders:8080/orders/1" {
+		t.Error("Should not change the hrefs of reused options")
+		t.Errorf("Wanted %v, got %v", "http://orders:8080/orders/1", c.collection.Items[0].Links[0].Href)
+	}
+}
+
+func TestBaseFromRequest(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		tls    bool
+		want   string
+	}{
+		{http.Header{}, false, "http://orders:8080"},
+		{http.Header{}, true, "https://orders:8080"},
+		{
+			http.Header{"Forwarded": {`for=192.0.2.60;proto=https;host="api.example.com", for=10.0.0.1`}},
+			false, "https://api.example.com",
+		},
+		{
+			http.Header{
+				"X-Forwarded-Proto":  {"https"},
+				"X-Forwarded-Host":   {"api.example.com, proxy.internal"},
+				"X-Forwarded-Prefix": {"/v1"},
+			},
+			false, "https://api.example.com/v1",
+		},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://orders:8080/orders/", nil)
+		r.Header = test.header
+		if test.tls {
+			r.TLS = &tls.ConnectionState{}
+		}
+		got := BaseFromRequest(r)
+		if got.String() != test.want {
+			t.Errorf("Wanted %v, got %v", test.want, got.String())
+		}
+	}
+}
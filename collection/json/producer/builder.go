@@ -0,0 +1,157 @@
+package producer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/skriptble/hyper/collection/json"
+)
+
+// BuildError is an error recorded by a Builder. Location describes the call
+// to the Builder the error came from, e.g. `item 2 "/friends/jdoe"`.
+type BuildError struct {
+	Location string
+	Err      error
+}
+
+func (e *BuildError) Error() string {
+	return "producer: " + e.Location + ": " + e.Err.Error()
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+// Builder builds a Collection through chained method calls. Instead of
+// stopping at the first error, a Builder records every error along with where
+// it came from and returns them together from Collection. This includes the
+// error of each of the options given to Item, Query, and Template.
+//
+//	c, err := Build().
+//		Link(self, "self", "", "", "").
+//		Item(href, NewDatum("full-name", "J. Doe", "Full Name")).
+//		Query(search, "search", "search", "Search", NewDatum("q", "", "")).
+//		Collection()
+type Builder struct {
+	steps []step
+	count map[string]int
+}
+
+// step is a single call to a Builder, holding either the Option created by
+// the call or the error from creating it.
+type step struct {
+	location string
+	opt      Option
+	err      error
+}
+
+// Build returns a new Builder.
+func Build() *Builder {
+	return &Builder{count: make(map[string]int)}
+}
+
+// Option adds options to the collection. Nil options are skipped.
+func (b *Builder) Option(opts ...Option) *Builder {
+	for _, opt := range opts {
+		if opt != nil {
+			b.add(b.location("option", ""), opt, nil)
+		}
+	}
+	return b
+}
+
+// Link adds a link to the collection, see NewLink.
+func (b *Builder) Link(href url.URL, rel, name, render, prompt string) *Builder {
+	return b.add(b.location("link", rel), NewLink(href, rel, name, render, prompt), nil)
+}
+
+// Item adds an item to the collection, see NewItem.
+func (b *Builder) Item(href url.URL, opts ...Option) *Builder {
+	return b.nested(b.location("item", href.String()), opts, func(opts ...Option) (Option, error) {
+		return NewItem(href, opts...)
+	})
+}
+
+// Items adds the items marshaled from v to the collection, see NewItems.
+func (b *Builder) Items(v interface{}) *Builder {
+	opt, err := NewItems(v)
+	return b.add(b.location("items", ""), opt, err)
+}
+
+// Query adds a query to the collection, see NewQuery.
+func (b *Builder) Query(href url.URL, rel, name, prompt string, opts ...Option) *Builder {
+	return b.nested(b.location("query", defaultString(name, rel)), opts, func(opts ...Option) (Option, error) {
+		return NewQuery(href, rel, name, prompt, opts...)
+	})
+}
+
+// Template sets the template of the collection, see NewTemplate.
+func (b *Builder) Template(opts ...Option) *Builder {
+	return b.nested(b.location("template", ""), opts, NewTemplate)
+}
+
+// Error sets the error of the collection, see NewError.
+func (b *Builder) Error(title, code, message string) *Builder {
+	return b.add(b.location("error", ""), NewError(title, code, message), nil)
+}
+
+// Collection returns the built Collection. If any errors were recorded they
+// are returned as *BuildErrors joined together with errors.Join.
+func (b *Builder) Collection() (Collection, error) {
+	c := new(collection)
+	c.Version = cj.V1
+	var errs []error
+	for _, s := range b.steps {
+		err := s.err
+		if err == nil {
+			err = s.opt(c)
+		}
+		if err != nil {
+			errs = append(errs, &BuildError{Location: s.location, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return Collection{}, errors.Join(errs...)
+	}
+	return finish(c), nil
+}
+
+func (b *Builder) add(location string, opt Option, err error) *Builder {
+	b.steps = append(b.steps, step{location: location, opt: opt, err: err})
+	return b
+}
+
+// nested adds the Option created from opts by create. If creating it fails,
+// the error of each of opts is recorded rather than only the first.
+func (b *Builder) nested(location string, opts []Option, create func(...Option) (Option, error)) *Builder {
+	opt, err := create(opts...)
+	if err == nil {
+		return b.add(location, opt, nil)
+	}
+	var errs []error
+	for _, o := range opts {
+		_, err := create(o)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		errs = append(errs, err)
+	}
+	for _, err := range errs {
+		b.add(location, nil, err)
+	}
+	return b
+}
+
+// location returns the location of the next call for kind, numbering each
+// kind separately.
+func (b *Builder) location(kind, detail string) string {
+	n := b.count[kind]
+	b.count[kind]++
+	if detail == "" {
+		return fmt.Sprintf("%s %d", kind, n)
+	}
+	return fmt.Sprintf("%s %d %q", kind, n, detail)
+}
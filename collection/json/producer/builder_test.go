@@ -0,0 +1,79 @@
+package producer
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	// Should be able to build a collection
+	href := url.URL{Scheme: "http", Host: "example.com", Path: "/friends/"}
+	c, err := Build().
+		Link(href, "self", "", "", "").
+		Item(href, NewDatum("full-name", "J. Doe", "Full Name")).
+		Query(href, "search", "search", "Search", NewDatum("q", "", "")).
+		Template(NewDatum("full-name", "", "Full Name")).
+		Collection()
+	if err != nil {
+		t.Errorf("Unexpected error from Collection: %v", err)
+	}
+	want, err := NewCollection(
+		NewLink(href, "self", "", "", ""),
+		mustOption(NewItem(href, NewDatum("full-name", "J. Doe", "Full Name"))),
+		mustOption(NewQuery(href, "search", "search", "Search", NewDatum("q", "", ""))),
+		mustOption(NewTemplate(NewDatum("full-name", "", "Full Name"))),
+	)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	if !reflect.DeepEqual(want, c) {
+		t.Error("Should be able to build a collection")
+		t.Errorf("Wanted %+v, got %+v", want, c)
+	}
+
+	// Should report every error with its location
+	errOpt := NewError("foo", "bar", "baz")
+	_, err = Build().
+		Item(href).
+		Item(url.URL{Path: "/friends/jdoe"}, errOpt, NewDatum("full-name", "J. Doe", ""), NewHref(href)).
+		Query(href, "search", "search", "", errOpt).
+		Option(NewDatum("foo", "", "")).
+		Items("foo").
+		Collection()
+	wantErrs := []string{
+		`producer: item 1 "/friends/jdoe": ` + ErrTypeUnknown.Error(),
+		`producer: item 1 "/friends/jdoe": ` + ErrTypeUnknown.Error(),
+		`producer: query 0 "search": ` + ErrTypeUnknown.Error(),
+		`producer: option 0: ` + ErrTypeUnknown.Error(),
+		`producer: items 0: ` + ErrUnsupportedType.Error(),
+	}
+	errs := err.(interface{ Unwrap() []error }).Unwrap()
+	if len(errs) != len(wantErrs) {
+		t.Error("Should report every error with its location")
+		t.Errorf("Wanted %d errors, got %v", len(wantErrs), err)
+	}
+	for i := 0; i < len(errs) && i < len(wantErrs); i++ {
+		if errs[i].Error() != wantErrs[i] {
+			t.Errorf("Wanted %v, got %v", wantErrs[i], errs[i])
+		}
+	}
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Error("Should be able to unwrap the errors of a builder")
+	}
+
+	// Should skip nil options
+	_, err = Build().Option(nil, NewHref(href)).Collection()
+	if err != nil {
+		t.Error("Should skip nil options")
+		t.Errorf("Unexpected error from Collection: %v", err)
+	}
+}
+
+func mustOption(opt Option, err error) Option {
+	if err != nil {
+		panic(err)
+	}
+	return opt
+}
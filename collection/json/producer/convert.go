@@ -0,0 +1,130 @@
+package producer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/skriptble/hyper/collection/json"
+	"github.com/skriptble/hyper/collection/json/consumer"
+)
+
+// FromConsumer creates a Collection from a Collection retrieved by a consumer,
+// then configures it with the given options. This allows a document retrieved
+// from another service to be decorated, e.g. by adding links or filtering
+// items, and served again without rebuilding it.
+//
+// The values of data are kept as they were in the consumed document, numbers
+// are held as json.Number. Properties added by extensions are kept and served
+// along with the collection.
+func FromConsumer(src consumer.Collection, opts ...Option) (Collection, error) {
+	b, err := src.MarshalJSON()
+	if err != nil {
+		return Collection{}, err
+	}
+	c, err := decodeCollection(b)
+	if err != nil {
+		return Collection{}, err
+	}
+	for _, opt := range opts {
+		err := opt(c)
+		if err != nil {
+			return Collection{}, err
+		}
+	}
+	return finish(c), nil
+}
+
+// UnmarshalJSON sets c to the Collection+JSON document in b, e.g. to load a
+// canned response from a file. The Collection can then be extended using With.
+// As with FromConsumer, numbers are held as json.Number and properties added
+// by extensions are kept.
+//
+// The document must hold what the Option constructors require: a known
+// version, an href and a rel on every link and query, and a name on every
+// datum. Unlike Validate, items without an href and relative hrefs are
+// accepted, so the output of Marshal or Write can be loaded. Every problem
+// found is returned as a *ValidationError, joined together with errors.Join.
+func (c *Collection) UnmarshalJSON(b []byte) error {
+	col, err := decodeCollection(b)
+	if err != nil {
+		return err
+	}
+	err = col.checkLoaded()
+	if err != nil {
+		return err
+	}
+	*c = Collection{*col}
+	return nil
+}
+
+// decodeCollection converts a Collection+JSON document into a collection.
+func decodeCollection(b []byte) (*collection, error) {
+	var document struct {
+		C *collection `json:"collection"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	err := dec.Decode(&document)
+	if err != nil {
+		return nil, err
+	}
+	if document.C == nil {
+		return nil, &ValidationError{Path: "collection", Problem: "missing collection"}
+	}
+	if document.C.Version == "" {
+		document.C.Version = cj.V1
+	}
+	return document.C, nil
+}
+
+// checkLoaded checks that col holds what the Option constructors require.
+func (col *collection) checkLoaded() error {
+	var v validator
+	if col.Version != "" && col.Version != cj.V1 {
+		v.add("collection", fmt.Sprintf("unknown version %q", col.Version))
+	}
+	v.required("links", col.Links)
+	for i, itm := range col.Items {
+		path := fmt.Sprintf("items[%d]", i)
+		v.names(path, itm.Data)
+		v.required(path+".links", itm.Links)
+	}
+	for i, q := range col.Queries {
+		path := fmt.Sprintf("queries[%d]", i)
+		if q.Href == "" {
+			v.add(path, "missing href")
+		}
+		if q.Rel == "" {
+			v.add(path, "missing rel")
+		}
+		v.names(path, q.Data)
+	}
+	if col.Template != nil {
+		v.names("template", col.Template.Data)
+	}
+	return errors.Join(v.errs...)
+}
+
+// required checks that each of links has an href and a rel.
+func (v *validator) required(path string, links []link) {
+	for i, l := range links {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		if l.Href == "" {
+			v.add(p, "missing href")
+		}
+		if l.Rel == "" {
+			v.add(p, "missing rel")
+		}
+	}
+}
+
+// names checks that each of data has a name.
+func (v *validator) names(path string, data []datum) {
+	for i, d := range data {
+		if d.Name == "" {
+			v.add(fmt.Sprintf("%s.data[%d]", path, i), "missing name")
+		}
+	}
+}
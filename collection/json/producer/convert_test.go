@@ -0,0 +1,234 @@
+package producer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/skriptble/hyper/collection/json/consumer"
+)
+
+func TestFromConsumer(t *testing.T) {
+	doc := `{"collection":{"version":"1.0","href":"http://example.com/friends/","items":[` +
+		`{"href":"http://example.com/friends/jdoe","data":[{"name":"age","value":42}]},` +
+		`{"href":"http://example.com/friends/msmith","data":[{"name":"age","value":17}]}]}}`
+	src, err := consumer.NewCollection([]byte(doc))
+	if err != nil {
+		t.Errorf("Unexpected error from consumer.NewCollection: %v", err)
+	}
+
+	// Should be able to create an unchanged collection from a consumer
+	c, err := FromConsumer(src)
+	if err != nil {
+		t.Errorf("Unexpected error from FromConsumer: %v", err)
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Errorf("Unexpected error from json.Marshal: %v", err)
+	}
+	want := doc
+	got := fmt.Sprintf("%s", b)
+	if got != want {
+		t.Error("Should be able to create an unchanged collection from a consumer")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should be able to add links and filter items
+	href := url.URL{Scheme: "http", Host: "gateway.example.com", Path: "/profile"}
+	linkOpt := NewLink(href, "profile", "", "", "")
+	filterOpt := NewItemFilter(func(href string, data map[string]interface{}) bool {
+		age, err := data["age"].(json.Number).Int64()
+		return err == nil && age >= 18
+	})
+	c, err = FromConsumer(src, linkOpt, filterOpt)
+	if err != nil {
+		t.Errorf("Unexpected error from FromConsumer: %v", err)
+	}
+	b, err = json.Marshal(c)
+	if err != nil {
+		t.Errorf("Unexpected error from json.Marshal: %v", err)
+	}
+	want = `{"collection":{"version":"1.0","href":"http://example.com/friends/",` +
+		`"links":[{"href":"http://gateway.example.com/profile","rel":"profile"}],"items":[` +
+		`{"href":"http://example.com/friends/jdoe","data":[{"name":"age","value":42}]}]}}`
+	got = fmt.Sprintf("%s", b)
+	if got != want {
+		t.Error("Should be able to add links and filter items")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should keep the properties added by extensions
+	doc = `{"collection":{"version":"1.0","href":"http://example.com/friends/","paging":{"total":2},` +
+		`"links":[{"href":"http://example.com/rss","rel":"feed","hreflang":"en"}],` +
+		`"items":[{"href":"http://example.com/friends/jdoe","etag":"1","data":[{"name":"age","value":42,"type":"number"}]}],` +
+		`"queries":[{"href":"http://example.com/search","rel":"search","method":"get","data":[{"name":"q","required":true}]}],` +
+		`"template":{"data":[{"name":"age","pattern":"[0-9]+"}],"method":"put"},` +
+		`"error":{"title":"Partial","details":["timeout"]},` +
+		`"errors":[{"name":"age","code":"range","severity":2}]}}`
+	src, err = consumer.NewCollection([]byte(doc))
+	if err != nil {
+		t.Errorf("Unexpected error from consumer.NewCollection: %v", err)
+	}
+	c, err = FromConsumer(src, linkOpt)
+	if err != nil {
+		t.Errorf("Unexpected error from FromConsumer: %v", err)
+	}
+	b, err = json.Marshal(c)
+	if err != nil {
+		t.Errorf("Unexpected error from json.Marshal: %v", err)
+	}
+	var wantDoc, gotDoc map[string]interface{}
+	json.Unmarshal([]byte(doc), &wantDoc)
+	json.Unmarshal(b, &gotDoc)
+	links := wantDoc["collection"].(map[string]interface{})["links"].([]interface{})
+	wantDoc["collection"].(map[string]interface{})["links"] = append(links,
+		map[string]interface{}{"href": "http://gateway.example.com/profile", "rel": "profile"})
+	if !reflect.DeepEqual(wantDoc, gotDoc) {
+		t.Error("Should keep the properties added by extensions")
+		t.Errorf("Wanted %v, got %s", wantDoc, b)
+	}
+
+	// Should keep the properties that are present but empty
+	doc = `{"collection":{"version":"1.0","href":"http://example.com/friends/","links":[],` +
+		`"items":[{"href":"http://example.com/friends/jdoe","data":[{"name":"nickname","value":"","prompt":""}],"links":[]}],` +
+		`"template":{"data":[]}}}`
+	src, err = consumer.NewCollection([]byte(doc))
+	if err != nil {
+		t.Errorf("Unexpected error from consumer.NewCollection: %v", err)
+	}
+	c, err = FromConsumer(src)
+	if err != nil {
+		t.Errorf("Unexpected error from FromConsumer: %v", err)
+	}
+	b, err = json.Marshal(c)
+	if err != nil {
+		t.Errorf("Unexpected error from json.Marshal: %v", err)
+	}
+	wantDoc, gotDoc = nil, nil
+	json.Unmarshal([]byte(doc), &wantDoc)
+	json.Unmarshal(b, &gotDoc)
+	if !reflect.DeepEqual(wantDoc, gotDoc) {
+		t.Error("Should keep the properties that are present but empty")
+		t.Errorf("Wanted %v, got %s", doc, b)
+	}
+
+	// Should not be able to attach an item filter to an unknown type
+	_, err = NewItem(url.URL{}, filterOpt)
+	if err != ErrTypeUnknown {
+		t.Error("Should not be able to attach an item filter to an unknown type")
+		t.Errorf("Wanted %v, got %v", ErrTypeUnknown, err)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	// Should be able to load a document and extend it
+	doc := `{"collection":{"version":"1.0","href":"/friends/",` +
+		`"items":[{"href":"/friends/jdoe","data":[{"name":"age","value":42}]}],` +
+		`"queries":[{"href":"/search","rel":"search","data":[{"name":"q","value":""}]}],` +
+		`"template":{"data":[{"name":"age","prompt":"Age"}]}}}`
+	var c Collection
+	err := json.Unmarshal([]byte(doc), &c)
+	if err != nil {
+		t.Errorf("Unexpected error from json.Unmarshal: %v", err)
+	}
+	c, err = c.With(NewLink(url.URL{Path: "/friends/rss"}, "feed", "", "", ""))
+	if err != nil {
+		t.Errorf("Unexpected error from With: %v", err)
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Errorf("Unexpected error from json.Marshal: %v", err)
+	}
+	want := `{"collection":{"version":"1.0","href":"/friends/",` +
+		`"links":[{"href":"/friends/rss","rel":"feed"}],` +
+		`"items":[{"href":"/friends/jdoe","data":[{"name":"age","value":42}]}],` +
+		`"queries":[{"href":"/search","rel":"search","data":[{"name":"q","value":""}]}],` +
+		`"template":{"data":[{"name":"age","prompt":"Age"}]}}}`
+	if string(b) != want {
+		t.Error("Should be able to load a document and extend it")
+		t.Errorf("Wanted %v, got %s", want, b)
+	}
+
+	// Should not be able to load a document the Option constructors could not produce
+	doc = `{"collection":{"version":"2.0",` +
+		`"links":[{"href":"/friends/rss"}],` +
+		`"items":[{"href":"/friends/jdoe","data":[{"value":42}]}],` +
+		`"queries":[{"rel":"search"}]}}`
+	err = json.Unmarshal([]byte(doc), &c)
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("Wanted joined validation errors, got %v", err)
+	}
+	var problems []string
+	for _, err := range joined.Unwrap() {
+		problems = append(problems, err.Error())
+	}
+	wantProblems := []string{
+		`producer: collection: unknown version "2.0"`,
+		"producer: links[0]: missing rel",
+		"producer: items[0].data[0]: missing name",
+		"producer: queries[0]: missing href",
+	}
+	if fmt.Sprint(problems) != fmt.Sprint(wantProblems) {
+		t.Error("Should not be able to load a document the Option constructors could not produce")
+		t.Errorf("Wanted %v, got %v", wantProblems, problems)
+	}
+
+	// Should be able to load the output of Marshal and Write
+	type friend struct {
+		Name string
+		Age  int
+	}
+	marshaled, err := Marshal([]friend{{"a", 1}})
+	if err != nil {
+		t.Fatalf("Unexpected error from Marshal: %v", err)
+	}
+	itm, err := NewItem(url.URL{}, NewDatum("x", "y", ""))
+	if err != nil {
+		t.Fatalf("Unexpected error from NewItem: %v", err)
+	}
+	q1, _ := NewQuery(url.URL{Path: "/search"}, "search", "q", "")
+	q2, _ := NewQuery(url.URL{Path: "/find"}, "search", "q", "")
+	written, err := NewCollection(itm, q1, q2, NewLink(url.URL{Path: "/logo"}, "icon", "", "picture", ""))
+	if err != nil {
+		t.Fatalf("Unexpected error from NewCollection: %v", err)
+	}
+	w := httptest.NewRecorder()
+	err = Write(w, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, written)
+	if err != nil {
+		t.Fatalf("Unexpected error from Write: %v", err)
+	}
+	for _, doc := range [][]byte{marshaled, w.Body.Bytes()} {
+		var loaded Collection
+		err = json.Unmarshal(doc, &loaded)
+		if err != nil {
+			t.Error("Should be able to load the output of Marshal and Write")
+			t.Errorf("Unexpected error from json.Unmarshal: %v for %s", err, doc)
+			continue
+		}
+		b, err := json.Marshal(loaded)
+		if err != nil {
+			t.Errorf("Unexpected error from json.Marshal: %v", err)
+		}
+		if !bytes.Equal(b, bytes.TrimSpace(doc)) {
+			t.Error("Should be able to load the output of Marshal and Write")
+			t.Errorf("Wanted %s, got %s", doc, b)
+		}
+	}
+
+	// Should not be able to load a document without a collection
+	for _, doc := range []string{`{"collection":null}`, `{}`, `null`} {
+		err = json.Unmarshal([]byte(doc), &c)
+		var verr *ValidationError
+		if !errors.As(err, &verr) || verr.Path != "collection" {
+			t.Error("Should not be able to load a document without a collection")
+			t.Errorf("Wanted a *ValidationError for collection, got %v for %s", err, doc)
+		}
+	}
+}
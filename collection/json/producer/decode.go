@@ -0,0 +1,283 @@
+package producer
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// ErrNoTemplate is returned by NewDecoder when the Option it is given does not
+// add a template to a collection.
+var ErrNoTemplate = errors.New("producer: option does not define a template")
+
+// ErrTooLarge is returned when a submitted template is larger than the maximum
+// size of the Decoder.
+var ErrTooLarge = errors.New("producer: submitted template exceeds maximum size")
+
+// ErrInvalidBody is returned, wrapping the error of encoding/json, when a
+// submitted template is not a single JSON value of the write representation.
+var ErrInvalidBody = errors.New("producer: submitted template is not valid")
+
+// ErrUnknownField is returned when a submitted template contains a datum that
+// is not defined by the template of the Decoder.
+var ErrUnknownField = errors.New("producer: submitted template contains unknown field")
+
+// ErrMissingField is returned when a submitted template does not contain a
+// datum defined by the template of the Decoder.
+var ErrMissingField = errors.New("producer: submitted template is missing field")
+
+// ErrDuplicateField is returned when a submitted template contains more than
+// one datum with the same name.
+var ErrDuplicateField = errors.New("producer: submitted template contains duplicate field")
+
+// ErrInvalidValue is returned when the value of a datum cannot be stored in
+// the struct field it is decoded into.
+var ErrInvalidValue = errors.New("producer: invalid value for field")
+
+// datumError is an error wrapping ErrUnknownField, ErrMissingField,
+// ErrDuplicateField, or ErrInvalidValue for a single datum. It also wraps the FieldErrors describing
+// the problem to clients, so that it is served as a 400 with the errors
+// extension. Its own text may include internal details, such as the errors of
+// strconv, and is never served.
+type datumError struct {
+	err    error
+	field  FieldError
+	detail error
+}
+
+// newDatumError returns a datumError for the datum name. err is one of
+// ErrUnknownField, ErrMissingField, ErrDuplicateField, or ErrInvalidValue,
+// detail is optional.
+func newDatumError(err error, name string, detail error) error {
+	fe := FieldError{Name: name}
+	switch err {
+	case ErrUnknownField:
+		fe.Code, fe.Message = "unknown", "This field is not part of the template."
+	case ErrMissingField:
+		fe.Code, fe.Message = "missing", "This field is required."
+	case ErrDuplicateField:
+		fe.Code, fe.Message = "duplicate", "This field is submitted more than once."
+	default:
+		fe.Code, fe.Message = "invalid", "This value is not valid."
+	}
+	return &datumError{err: err, field: fe, detail: detail}
+}
+
+func (e *datumError) Error() string {
+	if e.detail == nil {
+		return fmt.Sprintf("%v: %q", e.err, e.field.Name)
+	}
+	return fmt.Sprintf("%v %q: %v", e.err, e.field.Name, e.detail)
+}
+
+func (e *datumError) Unwrap() []error {
+	return []error{e.err, FieldErrors{e.field}}
+}
+
+// Decoder decodes the write representation of a Collection+JSON template, as
+// sent by clients in the bodies of POST and PUT requests:
+//
+//	{"template":{"data":[{"name":"full-name","value":"J. Doe"}]}}
+//
+// The submitted data is checked against the template the Decoder was created
+// with. A Decoder is safe for concurrent use.
+type Decoder struct {
+	template template
+	maxBytes int64
+}
+
+// NewDecoder creates a Decoder for the template defined by tmpl, which is
+// usually created by NewTemplate. If maxBytes is greater than zero, submitted
+// templates larger than maxBytes are rejected with ErrTooLarge.
+func NewDecoder(tmpl Option, maxBytes int64) (Decoder, error) {
+	c := new(collection)
+	err := tmpl(c)
+	if err != nil {
+		return Decoder{}, err
+	}
+	if c.Template == nil {
+		return Decoder{}, ErrNoTemplate
+	}
+	return Decoder{template: *c.Template, maxBytes: maxBytes}, nil
+}
+
+// Decode reads a submitted template from r and stores its data in the struct
+// pointed to by v. Each datum is stored in the field of the same name, using
+// the same cj struct tags and field names as NewItems. A datum whose name does
+// not match a field is checked but not stored.
+//
+// The submitted template must contain every datum of the Decoder's template,
+// and only those, each once, otherwise an error wrapping ErrMissingField,
+// ErrUnknownField, or ErrDuplicateField is returned. A body that is not a
+// single JSON value holding a template returns an error wrapping
+// ErrInvalidBody.
+func (d Decoder) Decode(r io.Reader, v interface{}) error {
+	data, err := d.read(r)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrUnsupportedType
+	}
+	return unmarshalData(data, rv.Elem())
+}
+
+// read reads a submitted template from r and returns its data by name.
+func (d Decoder) read(r io.Reader) (map[string]interface{}, error) {
+	if d.maxBytes > 0 {
+		r = io.LimitReader(r, d.maxBytes+1)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if d.maxBytes > 0 && int64(len(b)) > d.maxBytes {
+		return nil, ErrTooLarge
+	}
+
+	var submitted struct {
+		Template struct {
+			Data []datum `json:"data"`
+		} `json:"template"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	err = dec.Decode(&submitted)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBody, err)
+	}
+	_, err = dec.Token()
+	if err != io.EOF {
+		return nil, fmt.Errorf("%w: data after the template", ErrInvalidBody)
+	}
+
+	known := make(map[string]struct{}, len(d.template.Data))
+	for _, dt := range d.template.Data {
+		known[dt.Name] = struct{}{}
+	}
+	data := make(map[string]interface{}, len(submitted.Template.Data))
+	for _, dt := range submitted.Template.Data {
+		if _, ok := known[dt.Name]; !ok {
+			return nil, newDatumError(ErrUnknownField, dt.Name, nil)
+		}
+		if _, ok := data[dt.Name]; ok {
+			return nil, newDatumError(ErrDuplicateField, dt.Name, nil)
+		}
+		data[dt.Name] = dt.Value
+	}
+	for _, dt := range d.template.Data {
+		if _, ok := data[dt.Name]; !ok {
+			return nil, newDatumError(ErrMissingField, dt.Name, nil)
+		}
+	}
+	return data, nil
+}
+
+// unmarshalData stores the values in data in the fields of the struct v.
+func unmarshalData(data map[string]interface{}, v reflect.Value) error {
+	fields, err := structFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if f.kind != fieldDatum {
+			continue
+		}
+		val, ok := data[f.name]
+		if !ok {
+			continue
+		}
+		err := setValue(v.FieldByIndex(f.index), val)
+		if err != nil {
+			return newDatumError(ErrInvalidValue, f.name, err)
+		}
+	}
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setValue stores val, which is a value decoded from JSON with UseNumber or a
+// string, in v. Strings and numbers are converted to the kind of v.
+func setValue(v reflect.Value, val interface{}) error {
+	if v.Kind() == reflect.Ptr {
+		if val == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), val)
+	}
+	if val == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	var s string
+	isString := true
+	switch t := val.(type) {
+	case string:
+		s = t
+	case json.Number:
+		s = t.String()
+	case bool:
+		s = strconv.FormatBool(t)
+	default:
+		isString = false
+	}
+
+	if isString && reflect.PtrTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if isString {
+		switch v.Kind() {
+		case reflect.String:
+			v.SetString(s)
+			return nil
+		case reflect.Bool:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return err
+			}
+			v.SetBool(b)
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+			if err != nil {
+				return err
+			}
+			v.SetInt(n)
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+			if err != nil {
+				return err
+			}
+			v.SetUint(n)
+			return nil
+		case reflect.Float32, reflect.Float64:
+			n, err := strconv.ParseFloat(s, v.Type().Bits())
+			if err != nil {
+				return err
+			}
+			v.SetFloat(n)
+			return nil
+		}
+	}
+
+	// Fall back to encoding/json for any other combination of value and type.
+	b, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v.Addr().Interface())
+}
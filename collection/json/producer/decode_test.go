@@ -0,0 +1,92 @@
+package producer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type friendForm struct {
+	FullName string    `cj:"datum,Full Name,full-name"`
+	Age      int       `cj:"datum,Age,age"`
+	Since    time.Time `cj:"datum,Since,since"`
+	Nickname *string   `cj:"datum,Nickname,nickname"`
+	Active   bool      `cj:"datum,Active,active"`
+}
+
+func TestDecoder(t *testing.T) {
+	tmplOpt, err := NewTemplate(
+		NewDatum("full-name", "", "Full Name"),
+		NewDatum("age", "", "Age"),
+		NewDatum("since", "", "Since"),
+		NewDatum("nickname", "", "Nickname"),
+		NewDatum("active", "", "Active"),
+	)
+	if err != nil {
+		t.Errorf("Unexpected error from NewTemplate: %v", err)
+	}
+	dec, err := NewDecoder(tmplOpt, 1024)
+	if err != nil {
+		t.Errorf("Unexpected error from NewDecoder: %v", err)
+	}
+
+	// Should be able to decode a submitted template into a struct
+	body := `{"template":{"data":[
+		{"name":"full-name","value":"J. Doe"},
+		{"name":"age","value":42},
+		{"name":"since","value":"2015-04-01T00:00:00Z"},
+		{"name":"nickname","value":null},
+		{"name":"active","value":"true"}
+	]}}`
+	got := friendForm{}
+	err = dec.Decode(strings.NewReader(body), &got)
+	if err != nil {
+		t.Errorf("Unexpected error from Decode: %v", err)
+	}
+	want := friendForm{
+		FullName: "J. Doe",
+		Age:      42,
+		Since:    time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC),
+		Active:   true,
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Error("Should be able to decode a submitted template into a struct")
+		t.Errorf("Wanted %+v, got %+v", want, got)
+	}
+
+	tests := []struct {
+		body string
+		want error
+	}{
+		{`{"template":{"data":[{"name":"full-name","value":"J. Doe"}]}}`, ErrMissingField},
+		{strings.Replace(body, `"nickname"`, `"email"`, 1), ErrUnknownField},
+		{strings.Replace(body, `42`, `"forty-two"`, 1), ErrInvalidValue},
+		{strings.Repeat(" ", 1024) + body, ErrTooLarge},
+		{`{not json`, ErrInvalidBody},
+		{`{"template":{"data":"x"}}`, ErrInvalidBody},
+		{body + `{}`, ErrInvalidBody},
+		{strings.Replace(body, `"nickname"`, `"age"`, 1), ErrDuplicateField},
+	}
+	for _, test := range tests {
+		err = dec.Decode(strings.NewReader(test.body), &friendForm{})
+		if !errors.Is(err, test.want) {
+			t.Errorf("Wanted %v, got %v", test.want, err)
+		}
+	}
+
+	// Should not be able to create a decoder without a template
+	_, err = NewDecoder(NewError("foo", "bar", "baz"), 0)
+	if err != ErrNoTemplate {
+		t.Error("Should not be able to create a decoder without a template")
+		t.Errorf("Wanted %v, got %v", ErrNoTemplate, err)
+	}
+
+	// Should not be able to decode into a value that is not a pointer to a struct
+	err = dec.Decode(strings.NewReader(body), friendForm{})
+	if err != ErrUnsupportedType {
+		t.Error("Should not be able to decode into a value that is not a pointer to a struct")
+		t.Errorf("Wanted %v, got %v", ErrUnsupportedType, err)
+	}
+}
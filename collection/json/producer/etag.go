@@ -0,0 +1,95 @@
+package producer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrPreconditionFailed is returned by CheckIfMatch when the If-Match header
+// of a request does not match the current entity tag of a resource. It is
+// served as a 412 by DefaultRegistry.
+var ErrPreconditionFailed = errors.New("producer: precondition failed")
+
+// ErrInvalidETag is returned by the Option created by NewETag when its entity
+// tag is not quoted or contains a double quote.
+var ErrInvalidETag = errors.New("producer: entity tag must be quoted")
+
+// NewETag creates an Option that sets the entity tag Write sends for a
+// collection, instead of the one computed by ETag. It is used to send the
+// version of a stored value, e.g. `"42"`. etag must be a quoted entity tag,
+// optionally weak, e.g. `W/"42"`, otherwise ErrInvalidETag is returned. The
+// entity tag is cleared when the collection is changed by With.
+func NewETag(etag string) Option {
+	opaque := strings.TrimPrefix(etag, "W/")
+	valid := len(opaque) >= 2 && opaque[0] == '"' && opaque[len(opaque)-1] == '"' &&
+		!strings.Contains(opaque[1:len(opaque)-1], `"`)
+	return func(i interface{}) error {
+		c, ok := i.(*collection)
+		if !ok {
+			return ErrTypeUnknown
+		}
+		if !valid {
+			return ErrInvalidETag
+		}
+		c.etag = etag
+		return nil
+	}
+}
+
+// ETag returns the strong entity tag of the collection. Unless it was set by
+// NewETag, it is the SHA-256 of the serialization of the collection. The
+// serialization is already canonical, the properties of objects are written
+// in a fixed order, so equal collections have equal entity tags regardless of
+// how they were built.
+func (c Collection) ETag() (string, error) {
+	if c.collection.etag != "" {
+		return c.collection.etag, nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return computeETag(b), nil
+}
+
+// computeETag returns the entity tag of b, the serialization of a collection.
+func computeETag(b []byte) string {
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// CheckIfMatch checks the If-Match header of r against etag, the current
+// entity tag of the resource r changes. It returns ErrPreconditionFailed if
+// the header is present and matches neither etag nor "*". Entity tags are
+// compared using the strong comparison of RFC 9110, weak tags never match.
+func CheckIfMatch(r *http.Request, etag string) error {
+	header := r.Header.Get("If-Match")
+	if header == "" || matchETag(header, etag, false) {
+		return nil
+	}
+	return ErrPreconditionFailed
+}
+
+// matchETag reports whether the list of entity tags in header matches etag.
+// If weak is true the weak comparison is used, otherwise weak tags never
+// match.
+func matchETag(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		isWeak := strings.HasPrefix(tag, "W/")
+		if isWeak && !weak {
+			continue
+		}
+		if strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package producer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	self := NewLink(url.URL{Path: "/friends/"}, "self", "", "", "")
+	itm, err := NewItem(url.URL{Path: "/friends/jdoe"}, NewDatum("full-name", "J. Doe", ""))
+	if err != nil {
+		t.Errorf("Unexpected error from NewItem: %v", err)
+	}
+	c, err := NewCollection(self, itm)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	etag, err := c.ETag()
+	if err != nil {
+		t.Errorf("Unexpected error from ETag: %v", err)
+	}
+
+	// Should compute the same entity tag for equal collections
+	loaded := Collection{}
+	err = loaded.UnmarshalJSON([]byte(`{"collection":{"items":[{"data":[{"value":"J. Doe","name":"full-name"}],` +
+		`"href":"/friends/jdoe"}],"links":[{"rel":"self","href":"/friends/"}],"version":"1.0"}}`))
+	if err != nil {
+		t.Errorf("Unexpected error from UnmarshalJSON: %v", err)
+	}
+	if other, _ := loaded.ETag(); other != etag {
+		t.Error("Should compute the same entity tag for equal collections")
+		t.Errorf("Wanted %v, got %v", etag, other)
+	}
+	if len(etag) != 66 || etag[0] != '"' || etag[65] != '"' {
+		t.Errorf("Wanted a quoted SHA-256, got %v", etag)
+	}
+
+	// Should compute different entity tags for different collections
+	changed, err := c.With(NewError("", "", ""))
+	if err != nil {
+		t.Errorf("Unexpected error from With: %v", err)
+	}
+	if other, _ := changed.ETag(); other == etag {
+		t.Error("Should compute different entity tags for different collections")
+	}
+
+	// Should send the entity tag and answer a matching If-None-Match with a 304
+	tests := []struct {
+		method    string
+		noneMatch string
+		status    int
+	}{
+		{http.MethodGet, "", http.StatusOK},
+		{http.MethodGet, etag, http.StatusNotModified},
+		{http.MethodHead, `"other", W/` + etag, http.StatusNotModified},
+		{http.MethodGet, "*", http.StatusNotModified},
+		{http.MethodGet, `"other"`, http.StatusOK},
+		{http.MethodPost, etag, http.StatusOK},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, "/friends/", nil)
+		if test.noneMatch != "" {
+			r.Header.Set("If-None-Match", test.noneMatch)
+		}
+		w := httptest.NewRecorder()
+		err := Write(w, r, 0, c)
+		if err != nil {
+			t.Errorf("Unexpected error from Write: %v", err)
+		}
+		if w.Code != test.status || w.Header().Get("ETag") != etag {
+			t.Errorf("Should answer %v with If-None-Match %v with a %v", test.method, test.noneMatch, test.status)
+			t.Errorf("Wanted %v %v, got %v %v", test.status, etag, w.Code, w.Header().Get("ETag"))
+		}
+		if test.status == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Errorf("Wanted an empty body, got %s", w.Body)
+		}
+	}
+
+	// Should send the entity tag set by NewETag
+	versioned, err := c.With(NewETag(`"7"`))
+	if err != nil {
+		t.Errorf("Unexpected error from With: %v", err)
+	}
+	w := httptest.NewRecorder()
+	Write(w, httptest.NewRequest(http.MethodGet, "/friends/", nil), 0, versioned)
+	if w.Header().Get("ETag") != `"7"` {
+		t.Error("Should send the entity tag set by NewETag")
+		t.Errorf("Wanted %v, got %v", `"7"`, w.Header().Get("ETag"))
+	}
+
+	// Should not be able to set an entity tag that is not quoted
+	for _, etag := range []string{"7", `"7`, `W/7`, `"a"b"`, `"`} {
+		_, err = c.With(NewETag(etag))
+		if err != ErrInvalidETag {
+			t.Error("Should not be able to set an entity tag that is not quoted")
+			t.Errorf("Wanted %v, got %v for %v", ErrInvalidETag, err, etag)
+		}
+	}
+	_, err = c.With(NewETag(`W/"7"`))
+	if err != nil {
+		t.Errorf("Unexpected error from With: %v", err)
+	}
+
+	// Should not send an entity tag for errors
+	w = httptest.NewRecorder()
+	Write(w, httptest.NewRequest(http.MethodGet, "/friends/", nil), 0, changed)
+	if w.Header().Get("ETag") != "" {
+		t.Error("Should not send an entity tag for errors")
+		t.Errorf("Wanted no ETag, got %v", w.Header().Get("ETag"))
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	tests := []struct {
+		match string
+		err   error
+	}{
+		{"", nil},
+		{`"7"`, nil},
+		{`"6", "7"`, nil},
+		{"*", nil},
+		{`"6"`, ErrPreconditionFailed},
+		{`W/"7"`, ErrPreconditionFailed},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodPut, "/friends/jdoe", nil)
+		if test.match != "" {
+			r.Header.Set("If-Match", test.match)
+		}
+		err := CheckIfMatch(r, `"7"`)
+		if err != test.err {
+			t.Errorf("Should check If-Match %v", test.match)
+			t.Errorf("Wanted %v, got %v", test.err, err)
+		}
+	}
+
+	// Should represent a failed precondition as a 412
+	m := DefaultRegistry.Lookup(ErrPreconditionFailed)
+	if m.Status != http.StatusPreconditionFailed {
+		t.Error("Should represent a failed precondition as a 412")
+		t.Errorf("Wanted %v, got %v", http.StatusPreconditionFailed, m.Status)
+	}
+}
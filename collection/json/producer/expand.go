@@ -0,0 +1,45 @@
+package producer
+
+import (
+	"net/url"
+
+	"github.com/skriptble/hyper/uritemplate"
+)
+
+// NewExpandedLink creates an Option that adds a link to a collection or item
+// whose href is the expansion of the RFC 6570 URI Template tmpl with vars, see
+// uritemplate.Template.Expand:
+//
+//	opt, err := NewExpandedLink("/orders/{id}{?fields}", map[string]interface{}{
+//		"id":     "1",
+//		"fields": []string{"total", "status"},
+//	}, "order", "", "", "Order")
+//
+// An error is returned if tmpl is not a valid template, or if its expansion is
+// not a valid URL.
+func NewExpandedLink(tmpl string, vars map[string]interface{}, rel, name, render, prompt string) (Option, error) {
+	href, err := expand(tmpl, vars)
+	if err != nil {
+		return nil, err
+	}
+	return NewLink(*href, rel, name, render, prompt), nil
+}
+
+// NewExpandedQuery creates an Option that adds a query to a collection whose
+// href is the expansion of the RFC 6570 URI Template tmpl with vars, in the
+// same way as NewExpandedLink. The remaining arguments are those of NewQuery.
+func NewExpandedQuery(tmpl string, vars map[string]interface{}, rel, name, prompt string, opts ...Option) (Option, error) {
+	href, err := expand(tmpl, vars)
+	if err != nil {
+		return nil, err
+	}
+	return NewQuery(*href, rel, name, prompt, opts...)
+}
+
+func expand(tmpl string, vars map[string]interface{}) (*url.URL, error) {
+	s, err := uritemplate.Expand(tmpl, vars)
+	if err != nil {
+		return nil, err
+	}
+	return url.Parse(s)
+}
@@ -0,0 +1,50 @@
+package producer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/skriptble/hyper/uritemplate"
+)
+
+func TestExpanded(t *testing.T) {
+	vars := map[string]interface{}{
+		"id":     "a/1",
+		"fields": []string{"total", "status"},
+	}
+
+	// Should be able to add a link with an expanded href
+	linkOpt, err := NewExpandedLink("http://example.com/orders/{id}{?fields}", vars, "order", "", "", "Order")
+	if err != nil {
+		t.Errorf("Unexpected error from NewExpandedLink: %v", err)
+	}
+
+	// Should be able to add a query with an expanded href
+	queryOpt, err := NewExpandedQuery("http://example.com/orders/{id}/lines{?fields*}", vars, "search", "lines", "Lines",
+		NewDatum("sku", "", "SKU"))
+	if err != nil {
+		t.Errorf("Unexpected error from NewExpandedQuery: %v", err)
+	}
+
+	c, err := NewCollection(linkOpt, queryOpt)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	want := "http://example.com/orders/a%2F1?fields=total,status"
+	if got := c.collection.Links[0].Href; got != want {
+		t.Error("Should be able to add a link with an expanded href")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+	want = "http://example.com/orders/a%2F1/lines?fields=total&fields=status"
+	if got := c.collection.Queries[0].Href; got != want {
+		t.Error("Should be able to add a query with an expanded href")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should not be able to expand an invalid template
+	_, err = NewExpandedLink("/orders/{id", vars, "order", "", "", "")
+	if !errors.Is(err, uritemplate.ErrInvalidTemplate) {
+		t.Error("Should not be able to expand an invalid template")
+		t.Errorf("Wanted %v, got %v", uritemplate.ErrInvalidTemplate, err)
+	}
+}
@@ -0,0 +1,80 @@
+package producer
+
+import (
+	"strings"
+
+	"github.com/skriptble/hyper/collection/json/internal/jsonext"
+)
+
+// FieldError describes a problem with the value of a single datum of a
+// submitted template.
+type FieldError struct {
+	// Name is the name of the datum.
+	Name    string
+	Title   string
+	Code    string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Title
+	}
+	return "producer: " + e.Name + ": " + msg
+}
+
+// FieldErrors is a list of FieldErrors. It can be returned from a HandlerFunc,
+// in which case the response is a 400 with the errors extension holding each
+// FieldError.
+type FieldErrors []FieldError
+
+func (fe FieldErrors) Error() string {
+	msgs := make([]string, len(fe))
+	for i, e := range fe {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+type cjFieldError struct {
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	ext jsonext.Extensions
+}
+
+func (e *cjFieldError) UnmarshalJSON(b []byte) error {
+	type plain cjFieldError
+	return jsonext.UnmarshalNumbers(b, (*plain)(e), &e.ext)
+}
+
+func (e cjFieldError) MarshalJSON() ([]byte, error) {
+	type plain cjFieldError
+	return jsonext.Marshal(plain(e), e.ext)
+}
+
+// NewFieldErrors creates an Option that adds errs to a collection using the
+// errors extension. Unlike the error element, which holds a single error, the
+// errors extension holds a list of errors each tied to the name of a datum:
+//
+//	"errors":[{"name":"email","title":"Invalid","message":"must contain an @"}]
+//
+// This allows clients to highlight the inputs of a template that need fixing.
+func NewFieldErrors(errs ...FieldError) Option {
+	fes := make([]cjFieldError, len(errs))
+	for i, e := range errs {
+		fes[i] = cjFieldError{Name: e.Name, Title: e.Title, Code: e.Code, Message: e.Message}
+	}
+	return func(i interface{}) error {
+		c, ok := i.(*collection)
+		if !ok {
+			return ErrTypeUnknown
+		}
+
+		c.Errors = append(c.Errors, fes...)
+		return nil
+	}
+}
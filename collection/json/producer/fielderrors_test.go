@@ -0,0 +1,51 @@
+package producer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFieldErrors(t *testing.T) {
+	// Should be able to add field errors to a collection
+	c, err := NewCollection(NewFieldErrors(
+		FieldError{Name: "email", Title: "Invalid", Message: "must contain an @"},
+		FieldError{Name: "age", Code: "range"},
+	))
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	b, err := c.MarshalJSON()
+	if err != nil {
+		t.Errorf("Unexpected error from MarshalJSON: %v", err)
+	}
+	want := `{"collection":{"version":"1.0","errors":[` +
+		`{"name":"email","title":"Invalid","message":"must contain an @"},` +
+		`{"name":"age","code":"range"}]}}`
+	if string(b) != want {
+		t.Error("Should be able to add field errors to a collection")
+		t.Errorf("Wanted %v, got %s", want, b)
+	}
+
+	// Should serve field errors returned from a handler as a bad request
+	h := HandlerFunc(func(r *http.Request) (Collection, error) {
+		return Collection{}, fmt.Errorf("creating friend: %w", FieldErrors{{Name: "email", Message: "required"}})
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/friends/", nil))
+	want = `{"collection":{"version":"1.0","href":"/friends/",` +
+		`"error":{"title":"Bad Request","code":"400","message":"One or more fields are invalid."},` +
+		`"errors":[{"name":"email","message":"required"}]}}`
+	if w.Code != http.StatusBadRequest || w.Body.String() != want {
+		t.Error("Should serve field errors returned from a handler as a bad request")
+		t.Errorf("Wanted 400 %s, got %d %s", want, w.Code, w.Body)
+	}
+
+	// Should not be able to attach field errors to an unknown type
+	_, err = NewTemplate(NewFieldErrors())
+	if err != ErrTypeUnknown {
+		t.Error("Should not be able to attach field errors to an unknown type")
+		t.Errorf("Wanted %v, got %v", ErrTypeUnknown, err)
+	}
+}
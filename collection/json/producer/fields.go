@@ -0,0 +1,241 @@
+package producer
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidTag is returned when a cj struct tag is malformed or is attached to
+// a field whose type cannot be used for the element the tag describes.
+var ErrInvalidTag = errors.New("producer: invalid cj struct tag")
+
+// ErrAmbiguousField is returned when the type of an untagged field implements
+// the interfaces of more than one element. A cj struct tag must be used to
+// select the element the field is marshaled into.
+var ErrAmbiguousField = errors.New("producer: field implements more than one element interface")
+
+type fieldKind int
+
+const (
+	_ fieldKind = iota
+	fieldDatum
+	fieldLink
+	fieldHref
+	fieldLinks
+	fieldQueries
+	fieldTemplate
+	fieldError
+)
+
+// elements returns the kinds of element t can be marshaled into via the
+// element interfaces.
+func elements(t reflect.Type) []fieldKind {
+	var kinds []fieldKind
+	if implements(t, linkMarshalerType) || implements(t, linksMarshalerType) ||
+		elemImplements(t, linkMarshalerType) {
+		kinds = append(kinds, fieldLinks)
+	}
+	if implements(t, queryMarshalerType) || implements(t, queriesMarshalerType) ||
+		elemImplements(t, queryMarshalerType) {
+		kinds = append(kinds, fieldQueries)
+	}
+	if implements(t, templateMarshalerType) {
+		kinds = append(kinds, fieldTemplate)
+	}
+	if implements(t, errorMarshalerType) {
+		kinds = append(kinds, fieldError)
+	}
+	if implements(t, datumMarshalerType) {
+		kinds = append(kinds, fieldDatum)
+	}
+	return kinds
+}
+
+func hasKind(kinds []fieldKind, kind fieldKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// field is a struct field that is marshaled into an element of a document.
+type field struct {
+	index  []int
+	kind   fieldKind
+	name   string
+	prompt string
+	rel    string
+	typ    reflect.Type
+	tagged bool
+}
+
+var (
+	urlType    = reflect.TypeOf(url.URL{})
+	urlPtrType = reflect.TypeOf(&url.URL{})
+)
+
+var fieldCache sync.Map
+
+// structFields returns the fields of the struct type t that are marshaled into
+// a document. A field is marshaled if it has a cj struct tag, if its type
+// implements one of the element interfaces, or, if it is untagged, exported,
+// and is a string or a number, as a datum named after the field. Anonymous
+// struct fields are flattened into their parent.
+//
+// The cj struct tag has the following forms:
+//
+//	`cj:"-"`                   the field is ignored
+//	`cj:"href"`                the field is the href of the item
+//	`cj:"link,rel"`            the field is a link with the given rel
+//	`cj:"datum,prompt,name"`   the field is a datum, prompt and name are optional
+//	`cj:"link"`, `cj:"query"`, `cj:"template"`, `cj:"error"`
+//	                           the field is marshaled using the interface of
+//	                           the element, for types that implement several
+func structFields(t reflect.Type) ([]field, error) {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]field), nil
+	}
+	fields, err := typeFields(t, nil)
+	if err != nil {
+		return nil, err
+	}
+	fieldCache.Store(t, fields)
+	return fields, nil
+}
+
+func typeFields(t reflect.Type, index []int) ([]field, error) {
+	fields := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		idx := append(append([]int(nil), index...), i)
+		tag, tagged := sf.Tag.Lookup("cj")
+		if tag == "-" {
+			continue
+		}
+		if !tagged && sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			embedded, err := typeFields(sf.Type, idx)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		f := field{index: idx, name: sf.Name, typ: sf.Type, tagged: tagged}
+		kinds := elements(sf.Type)
+		if !tagged {
+			switch {
+			case len(kinds) > 1:
+				return nil, ErrAmbiguousField
+			case len(kinds) == 1:
+				f.kind = kinds[0]
+			case isScalar(sf.Type):
+				f.kind = fieldDatum
+			default:
+				continue
+			}
+			fields = append(fields, f)
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		switch parts[0] {
+		case "href":
+			if len(parts) != 1 || !isLinkType(sf.Type) {
+				return nil, ErrInvalidTag
+			}
+			f.kind = fieldHref
+		case "link":
+			if len(parts) == 1 && hasKind(kinds, fieldLinks) {
+				f.kind = fieldLinks
+				break
+			}
+			if len(parts) != 2 || parts[1] == "" || !isLinkType(sf.Type) {
+				return nil, ErrInvalidTag
+			}
+			f.kind = fieldLink
+			f.rel = parts[1]
+		case "query":
+			if len(parts) != 1 || !hasKind(kinds, fieldQueries) {
+				return nil, ErrInvalidTag
+			}
+			f.kind = fieldQueries
+		case "template":
+			if len(parts) != 1 || !hasKind(kinds, fieldTemplate) {
+				return nil, ErrInvalidTag
+			}
+			f.kind = fieldTemplate
+		case "error":
+			if len(parts) != 1 || !hasKind(kinds, fieldError) {
+				return nil, ErrInvalidTag
+			}
+			f.kind = fieldError
+		case "datum":
+			if len(parts) > 3 {
+				return nil, ErrInvalidTag
+			}
+			f.kind = fieldDatum
+			if len(parts) > 1 {
+				f.prompt = parts[1]
+			}
+			if len(parts) > 2 && parts[2] != "" {
+				f.name = parts[2]
+			}
+		default:
+			return nil, ErrInvalidTag
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// isScalar reports whether t is a string or a number, or a pointer to one.
+func isScalar(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// isLinkType reports whether t can be used as an href.
+func isLinkType(t reflect.Type) bool {
+	switch t {
+	case urlType, urlPtrType:
+		return true
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.String
+}
+
+// hrefString returns the href held by v, which must be of a type accepted by
+// isLinkType. Empty strings and nil pointers return an empty string.
+func hrefString(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Type() == urlType {
+		u := v.Interface().(url.URL)
+		return u.String()
+	}
+	return v.String()
+}
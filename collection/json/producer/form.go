@@ -0,0 +1,162 @@
+package producer
+
+import (
+	"net/url"
+	"reflect"
+)
+
+// NewQueryFrom creates an Option that adds a query to a collection whose data
+// is derived from the struct v. Each field of v that NewItems would marshal
+// into a datum becomes a datum of the query, with the name and prompt from its
+// cj struct tag. Fields with a non-zero value are used as the default value of
+// their datum. Other fields, such as links, are ignored.
+//
+//	type OrderSearch struct {
+//		Status string    `cj:"datum,Status,status"`
+//		Since  time.Time `cj:"datum,Placed Since,since"`
+//	}
+//
+//	opt, err := NewQueryFrom(href, "search", "orders", "Search Orders", OrderSearch{})
+//
+// The query string of a request for the query can be decoded back into the
+// struct using DecodeQuery.
+func NewQueryFrom(href url.URL, rel, name, prompt string, v interface{}) (Option, error) {
+	data, err := structData(v)
+	if err != nil {
+		return nil, err
+	}
+	return NewQuery(href, rel, name, prompt, dataOption(data))
+}
+
+// NewTemplateFrom creates an Option that sets the template of a collection to
+// the data derived from the struct v, in the same way as NewQueryFrom. Since
+// the names of the data match the fields of v, a Decoder created from the
+// Option decodes submitted templates back into the struct:
+//
+//	opt, err := NewTemplateFrom(Friend{Active: true})
+//	dec, err := NewDecoder(opt, 1<<20)
+//	...
+//	var f Friend
+//	err = dec.Decode(r.Body, &f)
+func NewTemplateFrom(v interface{}) (Option, error) {
+	data, err := structData(v)
+	if err != nil {
+		return nil, err
+	}
+	return NewTemplate(dataOption(data))
+}
+
+// DecodeQuery stores the values of a query string in the struct pointed to by
+// v, using the same field names as NewQueryFrom. Parameters that do not match
+// a field are ignored, as are empty parameters for fields that are not
+// strings. Slice fields receive every value of their parameter, other fields
+// receive the first. If a value cannot be stored in its field an error
+// wrapping ErrInvalidValue is returned.
+//
+//	var search OrderSearch
+//	err := DecodeQuery(r.URL.Query(), &search)
+func DecodeQuery(values url.Values, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrUnsupportedType
+	}
+	rv = rv.Elem()
+	fields, err := structFields(rv.Type())
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		vals, ok := values[f.name]
+		if f.kind != fieldDatum || !ok || len(vals) == 0 {
+			continue
+		}
+		err := setParam(rv.FieldByIndex(f.index), vals)
+		if err != nil {
+			return newDatumError(ErrInvalidValue, f.name, err)
+		}
+	}
+	return nil
+}
+
+// setParam stores the values of a query parameter in v.
+func setParam(v reflect.Value, vals []string) error {
+	t := v.Type()
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 &&
+		!reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		s := reflect.MakeSlice(t, 0, len(vals))
+		for _, val := range vals {
+			if val == "" && !isString(t.Elem()) {
+				continue
+			}
+			elem := reflect.New(t.Elem()).Elem()
+			err := setValue(elem, val)
+			if err != nil {
+				return err
+			}
+			s = reflect.Append(s, elem)
+		}
+		v.Set(s)
+		return nil
+	}
+	if vals[0] == "" && !isString(t) {
+		return nil
+	}
+	return setValue(v, vals[0])
+}
+
+// isString reports whether t is a string, or a pointer to one.
+func isString(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.String
+}
+
+// structData returns the data derived from the datum fields of the struct v.
+// Fields with a zero value are given no value.
+func structData(v interface{}) ([]datum, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrUnsupportedType
+	}
+	fields, err := structFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var data []datum
+	for _, f := range fields {
+		if f.kind != fieldDatum {
+			continue
+		}
+		fv := rv.FieldByIndex(f.index)
+		if fv.IsZero() {
+			data = append(data, datum{Name: f.name, Prompt: f.prompt})
+			continue
+		}
+		d, _, err := marshalDatum(fv, f)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, d)
+	}
+	return data, nil
+}
+
+// dataOption returns an Option that adds data to a query or template.
+func dataOption(data []datum) Option {
+	return func(i interface{}) error {
+		switch t := i.(type) {
+		case *query:
+			t.Data = append(t.Data, data...)
+		case *template:
+			t.Data = append(t.Data, data...)
+		default:
+			return ErrTypeUnknown
+		}
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+package producer
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type orderSearch struct {
+	Status string    `cj:"datum,Status,status"`
+	Since  time.Time `cj:"datum,Placed Since,since"`
+	Limit  int       `cj:"datum,Limit,limit"`
+	Tags   []string  `cj:"datum,Tags,tag"`
+	Self   string    `cj:"href"`
+}
+
+func TestQueryFrom(t *testing.T) {
+	// Should be able to derive a query from a struct
+	href := url.URL{Scheme: "http", Host: "example.com", Path: "/orders/search"}
+	opt, err := NewQueryFrom(href, "search", "orders", "Search Orders", orderSearch{Limit: 20})
+	if err != nil {
+		t.Errorf("Unexpected error from NewQueryFrom: %v", err)
+	}
+	c, err := NewCollection(opt)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	want := query{
+		Href:   "http://example.com/orders/search",
+		Rel:    "search",
+		Name:   "orders",
+		Prompt: "Search Orders",
+		Data: []datum{
+			{Name: "status", Prompt: "Status"},
+			{Name: "since", Prompt: "Placed Since"},
+			{Name: "limit", Value: 20, Prompt: "Limit"},
+			{Name: "tag", Prompt: "Tags"},
+		},
+	}
+	if len(c.collection.Queries) != 1 || !reflect.DeepEqual(want, c.collection.Queries[0]) {
+		t.Error("Should be able to derive a query from a struct")
+		t.Errorf("Wanted %+v, got %+v", want, c.collection.Queries)
+	}
+
+	// Should not be able to derive a query from a value that is not a struct
+	_, err = NewQueryFrom(href, "search", "", "", []string{})
+	if err != ErrUnsupportedType {
+		t.Error("Should not be able to derive a query from a value that is not a struct")
+		t.Errorf("Wanted %v, got %v", ErrUnsupportedType, err)
+	}
+}
+
+func TestDecodeQuery(t *testing.T) {
+	// Should be able to decode a query string into a struct
+	values, _ := url.ParseQuery("status=open&since=2015-04-01T00:00:00Z&limit=&tag=a&tag=b&page=2")
+	got := orderSearch{Limit: 20}
+	err := DecodeQuery(values, &got)
+	if err != nil {
+		t.Errorf("Unexpected error from DecodeQuery: %v", err)
+	}
+	want := orderSearch{
+		Status: "open",
+		Since:  time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC),
+		Limit:  20,
+		Tags:   []string{"a", "b"},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Error("Should be able to decode a query string into a struct")
+		t.Errorf("Wanted %+v, got %+v", want, got)
+	}
+
+	// Should return an error for a value that does not fit its field
+	values, _ = url.ParseQuery("limit=ten")
+	err = DecodeQuery(values, &got)
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Error("Should return an error for a value that does not fit its field")
+		t.Errorf("Wanted %v, got %v", ErrInvalidValue, err)
+	}
+
+	// Should not be able to decode into a value that is not a pointer to a struct
+	err = DecodeQuery(values, got)
+	if err != ErrUnsupportedType {
+		t.Error("Should not be able to decode into a value that is not a pointer to a struct")
+		t.Errorf("Wanted %v, got %v", ErrUnsupportedType, err)
+	}
+}
+
+func TestTemplateFrom(t *testing.T) {
+	// Should be able to derive a template from a struct
+	opt, err := NewTemplateFrom(&friendForm{Active: true})
+	if err != nil {
+		t.Errorf("Unexpected error from NewTemplateFrom: %v", err)
+	}
+	c, err := NewCollection(opt)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	want := &template{Data: []datum{
+		{Name: "full-name", Prompt: "Full Name"},
+		{Name: "age", Prompt: "Age"},
+		{Name: "since", Prompt: "Since"},
+		{Name: "nickname", Prompt: "Nickname"},
+		{Name: "active", Value: true, Prompt: "Active"},
+	}}
+	if !reflect.DeepEqual(want, c.collection.Template) {
+		t.Error("Should be able to derive a template from a struct")
+		t.Errorf("Wanted %+v, got %+v", want, c.collection.Template)
+	}
+
+	// Should be able to decode a submitted template into the same struct
+	dec, err := NewDecoder(opt, 0)
+	if err != nil {
+		t.Errorf("Unexpected error from NewDecoder: %v", err)
+	}
+	body := `{"template":{"data":[
+		{"name":"full-name","value":"J. Doe"},
+		{"name":"age","value":42},
+		{"name":"since","value":"2015-04-01T00:00:00Z"},
+		{"name":"nickname","value":"jd"},
+		{"name":"active","value":false}
+	]}}`
+	got := friendForm{}
+	err = dec.Decode(strings.NewReader(body), &got)
+	if err != nil {
+		t.Errorf("Unexpected error from Decode: %v", err)
+	}
+	nickname := "jd"
+	wantForm := friendForm{
+		FullName: "J. Doe",
+		Age:      42,
+		Since:    time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC),
+		Nickname: &nickname,
+	}
+	if !reflect.DeepEqual(wantForm, got) {
+		t.Error("Should be able to decode a submitted template into the same struct")
+		t.Errorf("Wanted %+v, got %+v", wantForm, got)
+	}
+}
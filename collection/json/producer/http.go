@@ -0,0 +1,128 @@
+package producer
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/skriptble/hyper/collection/json"
+)
+
+// StatusError is an error that can be returned from a HandlerFunc to control
+// the status code and C+J error of the response.
+type StatusError struct {
+	Status  int
+	Title   string
+	Code    string
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return "producer: " + e.Message
+	}
+	return "producer: " + http.StatusText(e.Status)
+}
+
+// Write writes c to w as a Collection+JSON document. If status is zero it is
+// taken from the code of the collection's error when that is an HTTP status
+// code, 500 when it is not, and 200 when the collection has no error. The body
+// is not written for HEAD requests.
+//
+// Successful responses carry the entity tag of the collection in the ETag
+// header, see Collection.ETag. A GET or HEAD request with an If-None-Match
+// header matching the entity tag is answered with a 304 without a body.
+func Write(w http.ResponseWriter, r *http.Request, status int, c Collection) error {
+	resp, err := newResponse(r, status, c)
+	if err != nil {
+		return err
+	}
+	return resp.write(w, r)
+}
+
+// response is a Collection ready to be written. Preparing the response before
+// writing it separates the errors that occur before anything is written from
+// those that occur while writing the body.
+type response struct {
+	status      int
+	etag        string
+	notModified bool
+	body        []byte
+}
+
+func newResponse(r *http.Request, status int, c Collection) (response, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return response{}, err
+	}
+	if status == 0 {
+		status = c.status()
+	}
+	resp := response{status: status, body: b}
+	if status >= 200 && status < 300 {
+		resp.etag = c.collection.etag
+		if resp.etag == "" {
+			resp.etag = computeETag(b)
+		}
+		noneMatch := r.Header.Get("If-None-Match")
+		resp.notModified = status == http.StatusOK && (r.Method == http.MethodGet || r.Method == http.MethodHead) &&
+			noneMatch != "" && matchETag(noneMatch, resp.etag, true)
+	}
+	return resp, nil
+}
+
+func (resp response) write(w http.ResponseWriter, r *http.Request) error {
+	if resp.etag != "" {
+		w.Header().Set("ETag", resp.etag)
+	}
+	if resp.notModified {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	w.Header().Set("Content-Type", cj.MediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(resp.body)))
+	w.WriteHeader(resp.status)
+	if r.Method == http.MethodHead {
+		return nil
+	}
+	_, err := w.Write(resp.body)
+	return err
+}
+
+// status returns the HTTP status code for the collection.
+func (c Collection) status() int {
+	if c.collection.Error == nil {
+		return http.StatusOK
+	}
+	code, err := strconv.Atoi(c.collection.Error.Code)
+	if err != nil || code < 100 || code > 599 {
+		return http.StatusInternalServerError
+	}
+	return code
+}
+
+// HandlerFunc is an http.Handler that serves the Collection returned by the
+// function. If the function returns an error, the C+J error document from
+// DefaultRegistry is served instead. Use Registry.Handler to represent errors
+// with a different Registry.
+type HandlerFunc func(r *http.Request) (Collection, error)
+
+func (fn HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serve(DefaultRegistry, fn, w, r)
+}
+
+func serve(reg *Registry, fn HandlerFunc, w http.ResponseWriter, r *http.Request) {
+	c, err := fn(r)
+	status := 0
+	if err != nil {
+		c, status = reg.Collection(r, err)
+	}
+	resp, err := newResponse(r, status, c)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	// Once writing has started the status has been sent, an error here
+	// usually means the client has gone away and there is nothing to add.
+	resp.write(w, r)
+}
@@ -0,0 +1,139 @@
+package producer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/skriptble/hyper/collection/json"
+)
+
+func TestWrite(t *testing.T) {
+	// Should write the collection with the Collection+JSON media type
+	c, err := NewCollection()
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/friends/", nil)
+	err = Write(w, r, 0, c)
+	if err != nil {
+		t.Errorf("Unexpected error from Write: %v", err)
+	}
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != cj.MediaType {
+		t.Error("Should write the collection with the Collection+JSON media type")
+		t.Errorf("Wanted %d %s, got %d %s", http.StatusOK, cj.MediaType, w.Code, w.Header().Get("Content-Type"))
+	}
+	if w.Body.String() != `{"collection":{"version":"1.0"}}` {
+		t.Errorf("Unexpected body from Write: %s", w.Body)
+	}
+
+	// Should use the code of the collection's error as the status
+	c, err = NewCollection(NewError("Not Found", "404", "no such friend"))
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	w = httptest.NewRecorder()
+	Write(w, r, 0, c)
+	if w.Code != http.StatusNotFound {
+		t.Error("Should use the code of the collection's error as the status")
+		t.Errorf("Wanted %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	// Should not write a body for HEAD requests
+	w = httptest.NewRecorder()
+	Write(w, httptest.NewRequest(http.MethodHead, "/friends/", nil), http.StatusOK, c)
+	if w.Body.Len() != 0 {
+		t.Error("Should not write a body for HEAD requests")
+		t.Errorf("Wanted an empty body, got %s", w.Body)
+	}
+}
+
+func TestHandlerFunc(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		body   string
+	}{
+		{
+			nil, http.StatusOK,
+			`{"collection":{"version":"1.0"}}`,
+		},
+		{
+			fmt.Errorf("loading friend: %w", &StatusError{Status: http.StatusNotFound, Message: "no such friend"}),
+			http.StatusNotFound,
+			`{"collection":{"version":"1.0","href":"/friends/jdoe","error":{"title":"Not Found","code":"404","message":"no such friend"}}}`,
+		},
+		{
+			errors.New("connection refused"), http.StatusInternalServerError,
+			`{"collection":{"version":"1.0","href":"/friends/jdoe","error":{"title":"Internal Server Error","code":"500"}}}`,
+		},
+	}
+	for _, test := range tests {
+		h := HandlerFunc(func(r *http.Request) (Collection, error) {
+			if test.err != nil {
+				return Collection{}, test.err
+			}
+			return NewCollection()
+		})
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/friends/jdoe", nil))
+		if w.Code != test.status || w.Body.String() != test.body {
+			t.Errorf("Serving error %v", test.err)
+			t.Errorf("Wanted %d %s, got %d %s", test.status, test.body, w.Code, w.Body)
+		}
+	}
+}
+
+// failingWriter is a ResponseWriter whose body cannot be written.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+	headers int
+}
+
+func (w *failingWriter) WriteHeader(status int) {
+	w.headers++
+	w.ResponseRecorder.WriteHeader(status)
+}
+
+func (w *failingWriter) Write(b []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestHandlerFuncWriteFailure(t *testing.T) {
+	// Should not send an error response once the response has started
+	h := HandlerFunc(func(r *http.Request) (Collection, error) {
+		return NewCollection()
+	})
+	w := &failingWriter{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/friends/", nil))
+	if w.headers != 1 || w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Error("Should not send an error response once the response has started")
+		t.Errorf("Wanted 1 %v and no body, got %v %v %s", http.StatusOK, w.headers, w.Code, w.Body)
+	}
+
+	// Should send a 500 when the collection cannot be marshaled
+	h = HandlerFunc(func(r *http.Request) (Collection, error) {
+		return NewCollection(NewValidation(), NewLink(url.URL{Path: "/relative"}, "self", "", "", ""))
+	})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/friends/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Error("Should send a 500 when the collection cannot be marshaled")
+		t.Errorf("Wanted %v, got %v %s", http.StatusInternalServerError, rec.Code, rec.Body)
+	}
+
+	// Should serve the zero Collection with a version
+	h = HandlerFunc(func(r *http.Request) (Collection, error) {
+		return Collection{}, nil
+	})
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/friends/", nil))
+	if rec.Body.String() != `{"collection":{"version":"1.0"}}` {
+		t.Error("Should serve the zero Collection with a version")
+		t.Errorf("Wanted %v, got %s", `{"collection":{"version":"1.0"}}`, rec.Body)
+	}
+}
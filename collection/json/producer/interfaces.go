@@ -0,0 +1,179 @@
+package producer
+
+import "reflect"
+
+// LinkMarshaler is implemented by types that can marshal themselves into a
+// link. The returned Option is usually created by NewLink.
+//
+// For each of the element interfaces, an Option that is nil adds nothing, e.g.
+// for a type that has no link to add.
+type LinkMarshaler interface {
+	MarshalLink() (Option, error)
+}
+
+// LinksMarshaler is implemented by types that can marshal themselves into
+// several links.
+type LinksMarshaler interface {
+	MarshalLinks() ([]Option, error)
+}
+
+// DatumMarshaler is implemented by types that can marshal themselves into the
+// value of a datum. The name and prompt of the datum are taken from the field
+// the type is held in.
+type DatumMarshaler interface {
+	MarshalDatum() (interface{}, error)
+}
+
+// ItemMarshaler is implemented by types that can marshal themselves into an
+// item. The returned Option is usually created by NewItem.
+type ItemMarshaler interface {
+	MarshalItem() (Option, error)
+}
+
+// QueryMarshaler is implemented by types that can marshal themselves into a
+// query. The returned Option is usually created by NewQuery.
+type QueryMarshaler interface {
+	MarshalQuery() (Option, error)
+}
+
+// QueriesMarshaler is implemented by types that can marshal themselves into
+// several queries.
+type QueriesMarshaler interface {
+	MarshalQueries() ([]Option, error)
+}
+
+// TemplateMarshaler is implemented by types that can marshal themselves into a
+// template. The returned Option is usually created by NewTemplate.
+type TemplateMarshaler interface {
+	MarshalTemplate() (Option, error)
+}
+
+// ErrorMarshaler is implemented by types that can marshal themselves into an
+// error. The returned Option is usually created by NewError.
+type ErrorMarshaler interface {
+	MarshalError() (Option, error)
+}
+
+var (
+	linkMarshalerType     = reflect.TypeOf((*LinkMarshaler)(nil)).Elem()
+	linksMarshalerType    = reflect.TypeOf((*LinksMarshaler)(nil)).Elem()
+	datumMarshalerType    = reflect.TypeOf((*DatumMarshaler)(nil)).Elem()
+	itemMarshalerType     = reflect.TypeOf((*ItemMarshaler)(nil)).Elem()
+	queryMarshalerType    = reflect.TypeOf((*QueryMarshaler)(nil)).Elem()
+	queriesMarshalerType  = reflect.TypeOf((*QueriesMarshaler)(nil)).Elem()
+	templateMarshalerType = reflect.TypeOf((*TemplateMarshaler)(nil)).Elem()
+	errorMarshalerType    = reflect.TypeOf((*ErrorMarshaler)(nil)).Elem()
+)
+
+// implements reports whether t or a pointer to t implements iface.
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PtrTo(t).Implements(iface)
+}
+
+// marshaler returns the value of v as iface. If only a pointer to the type of
+// v implements iface, the value is copied so its address can be taken. It
+// returns false if v does not implement iface or is a nil pointer.
+func marshaler(v reflect.Value, iface reflect.Type) (interface{}, bool) {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, false
+	}
+	if v.Type().Implements(iface) {
+		return v.Interface(), true
+	}
+	if !reflect.PtrTo(v.Type()).Implements(iface) {
+		return nil, false
+	}
+	if !v.CanAddr() {
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(v)
+		v = cp
+	}
+	return v.Addr().Interface(), true
+}
+
+// marshalLinks returns the link options for v, which is a LinkMarshaler, a
+// LinksMarshaler, or a slice of LinkMarshalers.
+func marshalLinks(v reflect.Value) ([]Option, error) {
+	if m, ok := marshaler(v, linksMarshalerType); ok {
+		opts, err := m.(LinksMarshaler).MarshalLinks()
+		if err != nil {
+			return nil, err
+		}
+		return appendOptions(nil, opts...), nil
+	}
+	if m, ok := marshaler(v, linkMarshalerType); ok {
+		opt, err := m.(LinkMarshaler).MarshalLink()
+		if err != nil {
+			return nil, err
+		}
+		return appendOptions(nil, opt), nil
+	}
+	return marshalSlice(v, func(elem reflect.Value) (Option, error) {
+		m, ok := marshaler(elem, linkMarshalerType)
+		if !ok {
+			return nil, nil
+		}
+		return m.(LinkMarshaler).MarshalLink()
+	})
+}
+
+// marshalQueries returns the query options for v, which is a QueryMarshaler, a
+// QueriesMarshaler, or a slice of QueryMarshalers.
+func marshalQueries(v reflect.Value) ([]Option, error) {
+	if m, ok := marshaler(v, queriesMarshalerType); ok {
+		opts, err := m.(QueriesMarshaler).MarshalQueries()
+		if err != nil {
+			return nil, err
+		}
+		return appendOptions(nil, opts...), nil
+	}
+	if m, ok := marshaler(v, queryMarshalerType); ok {
+		opt, err := m.(QueryMarshaler).MarshalQuery()
+		if err != nil {
+			return nil, err
+		}
+		return appendOptions(nil, opt), nil
+	}
+	return marshalSlice(v, func(elem reflect.Value) (Option, error) {
+		m, ok := marshaler(elem, queryMarshalerType)
+		if !ok {
+			return nil, nil
+		}
+		return m.(QueryMarshaler).MarshalQuery()
+	})
+}
+
+func marshalSlice(v reflect.Value, fn func(reflect.Value) (Option, error)) ([]Option, error) {
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, nil
+	}
+	opts := make([]Option, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		opt, err := fn(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		opts = appendOptions(opts, opt)
+	}
+	return opts, nil
+}
+
+// appendOptions appends the Options in add that are not nil to opts. A nil
+// Option returned by a marshaler adds nothing.
+func appendOptions(opts []Option, add ...Option) []Option {
+	for _, opt := range add {
+		if opt != nil {
+			opts = append(opts, opt)
+		}
+	}
+	return opts
+}
+
+// elemImplements reports whether t is a slice or array whose elements
+// implement iface.
+func elemImplements(t, iface reflect.Type) bool {
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return false
+	}
+	return implements(t.Elem(), iface)
+}
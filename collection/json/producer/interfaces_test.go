@@ -0,0 +1,148 @@
+package producer
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+type money int
+
+func (m money) MarshalDatum() (interface{}, error) {
+	return fmt.Sprintf("$%d.%02d", m/100, m%100), nil
+}
+
+type related string
+
+func (r related) MarshalLink() (Option, error) {
+	href := url.URL{Path: "/friends/" + string(r)}
+	return NewLink(href, "related", string(r), "", ""), nil
+}
+
+type search struct{}
+
+func (search) MarshalQuery() (Option, error) {
+	return NewQuery(url.URL{Path: "/search"}, "search", "", "Search")
+}
+
+type writeTemplate struct{}
+
+func (*writeTemplate) MarshalTemplate() (Option, error) {
+	return NewTemplate(NewDatum("full-name", "", "Full Name"))
+}
+
+type custom struct{}
+
+func (custom) MarshalItem() (Option, error) {
+	return NewItem(url.URL{Path: "/custom"})
+}
+
+type ambiguous struct{}
+
+type none struct{}
+
+func (none) MarshalLink() (Option, error)     { return nil, nil }
+func (none) MarshalItem() (Option, error)     { return nil, nil }
+func (none) MarshalQuery() (Option, error)    { return nil, nil }
+func (none) MarshalTemplate() (Option, error) { return nil, nil }
+func (none) MarshalError() (Option, error)    { return nil, nil }
+
+type noLinks struct{}
+
+func (noLinks) MarshalLinks() ([]Option, error) { return []Option{nil}, nil }
+
+func (ambiguous) MarshalQuery() (Option, error) { return nil, nil }
+func (ambiguous) MarshalError() (Option, error) { return NewError("foo", "bar", "baz"), nil }
+
+func TestMarshalers(t *testing.T) {
+	v := struct {
+		Href     string `cj:"href"`
+		Balance  money  `cj:"datum,Balance,balance"`
+		Friends  []related
+		Search   search
+		Template writeTemplate
+	}{
+		Href:    "/friends/jdoe",
+		Balance: 1234,
+		Friends: []related{"msmith", "rwilliams"},
+	}
+
+	// Should marshal fields using the element interfaces they implement
+	b, err := Marshal(v)
+	if err != nil {
+		t.Errorf("Unexpected error from Marshal: %v", err)
+	}
+	want := `{"collection":{"version":"1.0","items":[{"href":"/friends/jdoe",` +
+		`"data":[{"name":"balance","value":"$12.34","prompt":"Balance"}],` +
+		`"links":[{"href":"/friends/msmith","rel":"related","name":"msmith"},` +
+		`{"href":"/friends/rwilliams","rel":"related","name":"rwilliams"}]}],` +
+		`"queries":[{"href":"/search","rel":"search","prompt":"Search"}],` +
+		`"template":{"data":[{"name":"full-name","prompt":"Full Name"}]}}}`
+	got := fmt.Sprintf("%s", b)
+	if got != want {
+		t.Error("Should marshal fields using the element interfaces they implement")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should marshal structs implementing ItemMarshaler using MarshalItem
+	b, err = Marshal([]custom{{}})
+	if err != nil {
+		t.Errorf("Unexpected error from Marshal: %v", err)
+	}
+	want = `{"collection":{"version":"1.0","items":[{"href":"/custom"}]}}`
+	got = fmt.Sprintf("%s", b)
+	if got != want {
+		t.Error("Should marshal structs implementing ItemMarshaler using MarshalItem")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should not be able to marshal an untagged field implementing several interfaces
+	_, err = Marshal(struct{ Foo ambiguous }{})
+	if err != ErrAmbiguousField {
+		t.Error("Should not be able to marshal an untagged field implementing several interfaces")
+		t.Errorf("Wanted %v, got %v", ErrAmbiguousField, err)
+	}
+
+	// Should be able to select the interface with a struct tag
+	b, err = Marshal(struct {
+		Foo ambiguous `cj:"error"`
+	}{})
+	if err != nil {
+		t.Errorf("Unexpected error from Marshal: %v", err)
+	}
+	want = `{"collection":{"version":"1.0","error":{"title":"foo","code":"bar","message":"baz"}}}`
+	got = fmt.Sprintf("%s", b)
+	if got != want {
+		t.Error("Should be able to select the interface with a struct tag")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should add nothing for nil Options returned by marshalers
+	b, err = Marshal(struct {
+		Href     string  `cj:"href"`
+		Link     none    `cj:"link"`
+		Links    noLinks `cj:"link"`
+		Query    none    `cj:"query"`
+		Template none    `cj:"template"`
+		Error    none    `cj:"error"`
+	}{Href: "/friends/jdoe"})
+	if err != nil {
+		t.Errorf("Unexpected error from Marshal: %v", err)
+	}
+	want = `{"collection":{"version":"1.0","items":[{"href":"/friends/jdoe"}]}}`
+	got = fmt.Sprintf("%s", b)
+	if got != want {
+		t.Error("Should add nothing for nil Options returned by marshalers")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+	b, err = Marshal([]none{{}})
+	if err != nil {
+		t.Errorf("Unexpected error from Marshal: %v", err)
+	}
+	want = `{"collection":{"version":"1.0"}}`
+	got = fmt.Sprintf("%s", b)
+	if got != want {
+		t.Error("Should add nothing for nil Options returned by marshalers")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+}
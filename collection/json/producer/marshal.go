@@ -0,0 +1,242 @@
+package producer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+)
+
+// ErrUnsupportedType is returned when a value given to Marshal or NewItems is
+// not a struct, a pointer to a struct, or a slice of either.
+var ErrUnsupportedType = errors.New("producer: value must be a struct or a slice of structs")
+
+// Marshal returns the Collection+JSON document for v. If v is a struct it is
+// marshaled into the single item of the collection, if v is a slice of structs
+// each element is marshaled into an item. The collection is then configured by
+// the given options, e.g. to set its links or template.
+//
+// See NewItems for how a struct is marshaled into an item.
+func Marshal(v interface{}, opts ...Option) ([]byte, error) {
+	itemsOpt, err := NewItems(v)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewCollection(append([]Option{itemsOpt}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(c)
+}
+
+// NewItems creates an Option that adds v to a collection as items. If v is a
+// struct it is marshaled into a single item, if v is a slice of structs each
+// element is marshaled into an item.
+//
+// A struct that implements ItemMarshaler is marshaled using its MarshalItem
+// method. Otherwise the fields of the struct are marshaled according to their
+// cj struct tags. A field tagged `cj:"href"` is the href of the item and
+// fields tagged `cj:"link,rel"` are links with the given rel, both can be a
+// string, a url.URL, or a pointer to either. A field tagged
+// `cj:"datum,prompt,name"` is marshaled into a datum and can be of any type
+// that can be marshaled into JSON. Untagged fields that are exported and are a
+// string or a number are marshaled into a datum named after the field.
+//
+// Fields whose types implement the element interfaces are marshaled using
+// them. Links are added to the item, while queries, templates, and errors are
+// added to the collection. Multiple links or queries are aggregated, for
+// templates and errors the last one is used. A struct without an href, data,
+// or links adds no item.
+func NewItems(v interface{}) (Option, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		if _, ok := rv.Interface().(ItemMarshaler); ok {
+			break
+		}
+		rv = rv.Elem()
+	}
+
+	var opts []Option
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Ptr:
+		itemOpts, err := marshalStruct(rv)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, itemOpts...)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			itemOpts, err := marshalStruct(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, itemOpts...)
+		}
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	return collectionOptions(opts), nil
+}
+
+// NewItemFrom creates an Option that adds the struct v to a collection as an
+// item, in the same way as NewItems, but with href as the href of the item.
+// This is useful when the href of an item is not held by the struct itself,
+// e.g. when it is derived from the key the struct is stored under.
+func NewItemFrom(href url.URL, v interface{}) (Option, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrUnsupportedType
+	}
+	opt, err := NewItems(v)
+	if err != nil {
+		return nil, err
+	}
+	h := href.String()
+
+	return func(i interface{}) error {
+		c, ok := i.(*collection)
+		if !ok {
+			return ErrTypeUnknown
+		}
+
+		n := len(c.Items)
+		err := opt(c)
+		if err != nil {
+			return err
+		}
+		if len(c.Items) > n {
+			c.Items[n].Href = h
+		} else {
+			c.Items = append(c.Items, item{Href: h})
+		}
+		return nil
+	}, nil
+}
+
+// marshalStruct returns the options that add the struct v to a collection.
+func marshalStruct(v reflect.Value) ([]Option, error) {
+	if m, ok := marshaler(v, itemMarshalerType); ok {
+		opt, err := m.(ItemMarshaler).MarshalItem()
+		if err != nil {
+			return nil, err
+		}
+		return appendOptions(nil, opt), nil
+	}
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, ErrUnsupportedType
+	}
+
+	itm, opts, err := marshalItem(v)
+	if err != nil {
+		return nil, err
+	}
+	// A struct holding only queries, a template, or an error adds no item.
+	if itm.Href == "" && len(itm.Data) == 0 && len(itm.Links) == 0 {
+		return opts, nil
+	}
+	itemOpt := func(i interface{}) error {
+		c, ok := i.(*collection)
+		if !ok {
+			return ErrTypeUnknown
+		}
+
+		c.Items = append(c.Items, itm)
+		return nil
+	}
+	return append([]Option{itemOpt}, opts...), nil
+}
+
+// marshalItem marshals the struct v into an item. The options returned are
+// the queries, template, and error held by the fields of v.
+func marshalItem(v reflect.Value) (item, []Option, error) {
+	itm := item{}
+	var opts []Option
+	fields, err := structFields(v.Type())
+	if err != nil {
+		return itm, nil, err
+	}
+	for _, f := range fields {
+		fv := v.FieldByIndex(f.index)
+		switch f.kind {
+		case fieldHref:
+			itm.Href = hrefString(fv)
+		case fieldLink:
+			href := hrefString(fv)
+			if href == "" {
+				continue
+			}
+			itm.Links = append(itm.Links, link{Href: href, Rel: f.rel})
+		case fieldLinks:
+			linkOpts, err := marshalLinks(fv)
+			if err != nil {
+				return itm, nil, err
+			}
+			for _, opt := range linkOpts {
+				err := opt(&itm)
+				if err != nil {
+					return itm, nil, err
+				}
+			}
+		case fieldQueries:
+			queryOpts, err := marshalQueries(fv)
+			if err != nil {
+				return itm, nil, err
+			}
+			opts = append(opts, queryOpts...)
+		case fieldTemplate:
+			if m, ok := marshaler(fv, templateMarshalerType); ok {
+				opt, err := m.(TemplateMarshaler).MarshalTemplate()
+				if err != nil {
+					return itm, nil, err
+				}
+				opts = appendOptions(opts, opt)
+			}
+		case fieldError:
+			if m, ok := marshaler(fv, errorMarshalerType); ok {
+				opt, err := m.(ErrorMarshaler).MarshalError()
+				if err != nil {
+					return itm, nil, err
+				}
+				opts = appendOptions(opts, opt)
+			}
+		case fieldDatum:
+			d, ok, err := marshalDatum(fv, f)
+			if err != nil {
+				return itm, nil, err
+			}
+			if ok {
+				itm.Data = append(itm.Data, d)
+			}
+		}
+	}
+	return itm, opts, nil
+}
+
+// marshalDatum marshals the field f with the value v into a datum. It returns
+// false if the field should be omitted.
+func marshalDatum(v reflect.Value, f field) (datum, bool, error) {
+	d := datum{Name: f.name, Prompt: f.prompt}
+	if m, ok := marshaler(v, datumMarshalerType); ok {
+		val, err := m.(DatumMarshaler).MarshalDatum()
+		if err != nil {
+			return d, false, err
+		}
+		d.Value = val
+		return d, true, nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return d, f.tagged, nil
+		}
+		v = v.Elem()
+	}
+	d.Value = v.Interface()
+	return d, true, nil
+}
@@ -0,0 +1,117 @@
+package producer
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+type friend struct {
+	Href     url.URL  `cj:"href"`
+	Blog     string   `cj:"link,blog"`
+	Avatar   *url.URL `cj:"link,avatar"`
+	FullName string   `cj:"datum,Full Name,full-name"`
+	Email    string
+	Age      int
+	Active   bool `cj:"datum"`
+	Nickname *string
+	Internal string `cj:"-"`
+	friends  []string
+}
+
+func TestMarshal(t *testing.T) {
+	jdoe := friend{
+		Href:     url.URL{Scheme: "http", Host: "example.com", Path: "/friends/jdoe"},
+		Blog:     "http://example.com/blogs/jdoe",
+		FullName: "J. Doe",
+		Email:    "jdoe@example.org",
+		Age:      42,
+		Active:   true,
+		Internal: "secret",
+	}
+
+	// Should be able to marshal a struct into a collection with one item
+	b, err := Marshal(&jdoe)
+	if err != nil {
+		t.Errorf("Unexpected error from Marshal: %v", err)
+	}
+	want := `{"collection":{"version":"1.0","items":[{"href":"http://example.com/friends/jdoe",` +
+		`"data":[{"name":"full-name","value":"J. Doe","prompt":"Full Name"},` +
+		`{"name":"Email","value":"jdoe@example.org"},{"name":"Age","value":42},` +
+		`{"name":"Active","value":true}],` +
+		`"links":[{"href":"http://example.com/blogs/jdoe","rel":"blog"}]}]}}`
+	got := fmt.Sprintf("%s", b)
+	if got != want {
+		t.Error("Should be able to marshal a struct into a collection with one item")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should be able to marshal a slice of structs into items
+	nick := "MS"
+	msmith := friend{Href: url.URL{Path: "/friends/msmith"}, Nickname: &nick}
+	href := url.URL{Path: "/friends/"}
+	b, err = Marshal([]friend{msmith}, NewLink(href, "self", "", "", ""))
+	if err != nil {
+		t.Errorf("Unexpected error from Marshal: %v", err)
+	}
+	want = `{"collection":{"version":"1.0","links":[{"href":"/friends/","rel":"self"}],` +
+		`"items":[{"href":"/friends/msmith","data":[{"name":"full-name","value":"","prompt":"Full Name"},` +
+		`{"name":"Email","value":""},{"name":"Age","value":0},{"name":"Active","value":false},` +
+		`{"name":"Nickname","value":"MS"}]}]}}`
+	got = fmt.Sprintf("%s", b)
+	if got != want {
+		t.Error("Should be able to marshal a slice of structs into items")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should not be able to marshal a value that is not a struct
+	_, err = Marshal("foo")
+	if err != ErrUnsupportedType {
+		t.Error("Should not be able to marshal a value that is not a struct")
+		t.Errorf("Wanted %v, got %v", ErrUnsupportedType, err)
+	}
+
+	// Should not be able to marshal a struct with an invalid tag
+	_, err = Marshal(struct {
+		Foo int `cj:"link,foo"`
+	}{})
+	if err != ErrInvalidTag {
+		t.Error("Should not be able to marshal a struct with an invalid tag")
+		t.Errorf("Wanted %v, got %v", ErrInvalidTag, err)
+	}
+
+	// Should not be able to attach items to an unknown type
+	itemsOpt, err := NewItems(jdoe)
+	if err != nil {
+		t.Errorf("Unexpected error from NewItems: %v", err)
+	}
+	_, err = NewItem(url.URL{}, itemsOpt)
+	if err != ErrTypeUnknown {
+		t.Error("Should not be able to attach items to an unknown type")
+		t.Errorf("Wanted %v, got %v", ErrTypeUnknown, err)
+	}
+}
+
+func TestItemFrom(t *testing.T) {
+	// Should be able to marshal a struct into an item with a given href
+	opt, err := NewItemFrom(url.URL{Path: "/friends/1"}, friend{FullName: "J. Doe", Blog: "/blogs/jdoe"})
+	if err != nil {
+		t.Errorf("Unexpected error from NewItemFrom: %v", err)
+	}
+	c, err := NewCollection(opt)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	if len(c.collection.Items) != 1 || c.collection.Items[0].Href != "/friends/1" ||
+		len(c.collection.Items[0].Links) != 1 {
+		t.Error("Should be able to marshal a struct into an item with a given href")
+		t.Errorf("Wanted %v, got %+v", "/friends/1", c.collection.Items)
+	}
+
+	// Should not be able to marshal a slice into an item
+	_, err = NewItemFrom(url.URL{Path: "/friends/1"}, []friend{})
+	if err != ErrUnsupportedType {
+		t.Error("Should not be able to marshal a slice into an item")
+		t.Errorf("Wanted %v, got %v", ErrUnsupportedType, err)
+	}
+}
@@ -0,0 +1,182 @@
+package producer
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+// ErrInvalidPage is returned when a page has a limit less than one, or a
+// negative offset or total.
+var ErrInvalidPage = errors.New("producer: page must have a positive limit and non-negative offset and total")
+
+// Page describes a page of a listing that is paged by offset and limit.
+type Page struct {
+	// Offset is the index of the first item of the page.
+	Offset int
+	// Limit is the maximum number of items in a page.
+	Limit int
+	// Total is the total number of items in the listing. Nil means the
+	// total is unknown, in which case HasMore is used to decide whether
+	// there is a next page and no last link is added.
+	Total *int
+	// HasMore reports whether there are items after this page. It is only
+	// used when Total is nil.
+	HasMore bool
+	// OffsetParam and LimitParam are the names of the query parameters
+	// holding the offset and limit. They default to "offset" and "limit".
+	OffsetParam string
+	LimitParam  string
+}
+
+// CursorPage describes a page of a listing that is paged by opaque cursors.
+type CursorPage struct {
+	// Limit is the maximum number of items in a page.
+	Limit int
+	// Next and Prev are the cursors of the next and previous pages. An empty
+	// cursor means there is no such page.
+	Next string
+	Prev string
+	// CursorParam and LimitParam are the names of the query parameters
+	// holding the cursor and limit. They default to "cursor" and "limit".
+	CursorParam string
+	LimitParam  string
+}
+
+// NewPagination creates an Option that adds the paging links and query for p
+// to a collection. href is the URL of the current request, its query
+// parameters other than the offset and limit are kept in every link.
+//
+// The first link is always added, the prev and next links are added when
+// there is such a page, and the last link is added when the total is known.
+// A query with the rel and name "page" is added with offset and limit data.
+//
+// The pages are aligned to the offset of the current page, so following the
+// next links from any page reaches the last page. The prev page never
+// overlaps the current page, when fewer than a limit of items precede the
+// current page its limit is reduced. When the offset is past the total, the
+// prev page is the last page.
+func NewPagination(href url.URL, p Page) (Option, error) {
+	if p.Limit < 1 || p.Offset < 0 || (p.Total != nil && *p.Total < 0) {
+		return nil, ErrInvalidPage
+	}
+	offsetParam := defaultString(p.OffsetParam, "offset")
+	limitParam := defaultString(p.LimitParam, "limit")
+	limit := strconv.Itoa(p.Limit)
+	page := func(offset, limit int) url.URL {
+		return withParams(href, offsetParam, strconv.Itoa(offset), limitParam, strconv.Itoa(limit))
+	}
+
+	last := 0
+	if p.Total != nil {
+		last = lastOffset(p.Offset, p.Limit, *p.Total)
+	}
+	opts := []Option{NewLink(page(0, p.Limit), "first", "", "", "")}
+	if p.Offset > 0 {
+		prev, prevLimit := p.Offset-p.Limit, p.Limit
+		if p.Total != nil && prev > last {
+			prev = last
+		}
+		if prev < 0 {
+			prevLimit += prev
+			prev = 0
+		}
+		opts = append(opts, NewLink(page(prev, prevLimit), "prev", "", "", ""))
+	}
+	next := p.Offset + p.Limit
+	if (p.Total != nil && next < *p.Total) || (p.Total == nil && p.HasMore) {
+		opts = append(opts, NewLink(page(next, p.Limit), "next", "", "", ""))
+	}
+	if p.Total != nil {
+		opts = append(opts, NewLink(page(last, p.Limit), "last", "", "", ""))
+	}
+
+	queryOpt, err := NewQuery(withParams(href, offsetParam, "", limitParam, ""), "page", "page", "Page",
+		NewDatum(offsetParam, strconv.Itoa(p.Offset), "Offset"),
+		NewDatum(limitParam, limit, "Limit"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, queryOpt)
+	return collectionOptions(opts), nil
+}
+
+// lastOffset returns the offset of the last page, the last offset a multiple
+// of limit away from offset that is before total. It is zero when there is no
+// such offset.
+func lastOffset(offset, limit, total int) int {
+	if total <= 0 {
+		return 0
+	}
+	var last int
+	if offset < total {
+		last = offset + (total-1-offset)/limit*limit
+	} else {
+		back := (offset - total + limit) / limit
+		last = offset - back*limit
+	}
+	if last < 0 {
+		return 0
+	}
+	return last
+}
+
+// NewCursorPagination creates an Option that adds the paging links and query
+// for p to a collection. href is the URL of the current request, its query
+// parameters other than the cursor and limit are kept in every link.
+//
+// The first link is always added and the prev and next links are added when
+// their cursors are not empty. A query with the rel and name "page" is added
+// with cursor and limit data.
+func NewCursorPagination(href url.URL, p CursorPage) (Option, error) {
+	if p.Limit < 1 {
+		return nil, ErrInvalidPage
+	}
+	cursorParam := defaultString(p.CursorParam, "cursor")
+	limitParam := defaultString(p.LimitParam, "limit")
+	limit := strconv.Itoa(p.Limit)
+	page := func(cursor string) url.URL {
+		return withParams(href, cursorParam, cursor, limitParam, limit)
+	}
+
+	opts := []Option{NewLink(page(""), "first", "", "", "")}
+	if p.Prev != "" {
+		opts = append(opts, NewLink(page(p.Prev), "prev", "", "", ""))
+	}
+	if p.Next != "" {
+		opts = append(opts, NewLink(page(p.Next), "next", "", "", ""))
+	}
+
+	queryOpt, err := NewQuery(withParams(href, cursorParam, "", limitParam, ""), "page", "page", "Page",
+		NewDatum(cursorParam, "", "Cursor"),
+		NewDatum(limitParam, limit, "Limit"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, queryOpt)
+	return collectionOptions(opts), nil
+}
+
+// withParams returns a copy of href with the query parameters given as key,
+// value pairs set. Parameters with an empty value are removed.
+func withParams(href url.URL, pairs ...string) url.URL {
+	q := href.Query()
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			q.Del(pairs[i])
+			continue
+		}
+		q.Set(pairs[i], pairs[i+1])
+	}
+	href.RawQuery = q.Encode()
+	return href
+}
+
+func defaultString(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,190 @@
+package producer
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestPagination(t *testing.T) {
+	href, err := url.Parse("http://example.com/friends/?status=active&offset=10&limit=10")
+	if err != nil {
+		t.Errorf("Unexpected error from url.Parse: %v", err)
+	}
+	tests := []struct {
+		page  Page
+		links map[string]string
+	}{
+		{
+			Page{Offset: 10, Limit: 10, Total: intPtr(35)},
+			map[string]string{
+				"first": "http://example.com/friends/?limit=10&offset=0&status=active",
+				"prev":  "http://example.com/friends/?limit=10&offset=0&status=active",
+				"next":  "http://example.com/friends/?limit=10&offset=20&status=active",
+				"last":  "http://example.com/friends/?limit=10&offset=30&status=active",
+			},
+		},
+		{
+			Page{Offset: 30, Limit: 10, Total: intPtr(30)},
+			map[string]string{
+				"first": "http://example.com/friends/?limit=10&offset=0&status=active",
+				"prev":  "http://example.com/friends/?limit=10&offset=20&status=active",
+				"last":  "http://example.com/friends/?limit=10&offset=20&status=active",
+			},
+		},
+		{
+			Page{Offset: 0, Limit: 10, Total: intPtr(0)},
+			map[string]string{
+				"first": "http://example.com/friends/?limit=10&offset=0&status=active",
+				"last":  "http://example.com/friends/?limit=10&offset=0&status=active",
+			},
+		},
+		{
+			Page{Offset: 5, Limit: 10, Total: intPtr(35)},
+			map[string]string{
+				"first": "http://example.com/friends/?limit=10&offset=0&status=active",
+				"prev":  "http://example.com/friends/?limit=5&offset=0&status=active",
+				"next":  "http://example.com/friends/?limit=10&offset=15&status=active",
+				"last":  "http://example.com/friends/?limit=10&offset=25&status=active",
+			},
+		},
+		{
+			Page{Offset: 25, Limit: 10, Total: intPtr(35)},
+			map[string]string{
+				"first": "http://example.com/friends/?limit=10&offset=0&status=active",
+				"prev":  "http://example.com/friends/?limit=10&offset=15&status=active",
+				"last":  "http://example.com/friends/?limit=10&offset=25&status=active",
+			},
+		},
+		{
+			Page{Offset: 50, Limit: 10, Total: intPtr(35)},
+			map[string]string{
+				"first": "http://example.com/friends/?limit=10&offset=0&status=active",
+				"prev":  "http://example.com/friends/?limit=10&offset=30&status=active",
+				"last":  "http://example.com/friends/?limit=10&offset=30&status=active",
+			},
+		},
+		{
+			Page{Offset: 40, Limit: 10, Total: intPtr(35)},
+			map[string]string{
+				"first": "http://example.com/friends/?limit=10&offset=0&status=active",
+				"prev":  "http://example.com/friends/?limit=10&offset=30&status=active",
+				"last":  "http://example.com/friends/?limit=10&offset=30&status=active",
+			},
+		},
+		{
+			Page{Offset: 5, Limit: 10, Total: intPtr(3)},
+			map[string]string{
+				"first": "http://example.com/friends/?limit=10&offset=0&status=active",
+				"prev":  "http://example.com/friends/?limit=5&offset=0&status=active",
+				"last":  "http://example.com/friends/?limit=10&offset=0&status=active",
+			},
+		},
+		{
+			// Only HasMore is set, the total is unknown.
+			Page{Offset: 5, Limit: 10, HasMore: true, OffsetParam: "start"},
+			map[string]string{
+				"first": "http://example.com/friends/?limit=10&start=0&status=active",
+				"prev":  "http://example.com/friends/?limit=5&start=0&status=active",
+				"next":  "http://example.com/friends/?limit=10&start=15&status=active",
+			},
+		},
+	}
+	for _, test := range tests {
+		href := *href
+		if test.page.OffsetParam != "" {
+			href.RawQuery = "status=active&" + test.page.OffsetParam + "=10&limit=10"
+		}
+		pageOpt, err := NewPagination(href, test.page)
+		if err != nil {
+			t.Errorf("Unexpected error from NewPagination: %v", err)
+		}
+		c, err := NewCollection(pageOpt)
+		if err != nil {
+			t.Errorf("Unexpected error from NewCollection: %v", err)
+		}
+		got := make(map[string]string)
+		for _, l := range c.collection.Links {
+			got[l.Rel] = l.Href
+		}
+		if !reflect.DeepEqual(test.links, got) {
+			t.Errorf("Paging %+v", test.page)
+			t.Errorf("Wanted %v, got %v", test.links, got)
+		}
+	}
+
+	// Should add a paging query
+	pageOpt, err := NewPagination(*href, Page{Offset: 10, Limit: 10, Total: intPtr(35)})
+	if err != nil {
+		t.Errorf("Unexpected error from NewPagination: %v", err)
+	}
+	c, err := NewCollection(pageOpt)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	want := query{
+		Href:   "http://example.com/friends/?status=active",
+		Rel:    "page",
+		Name:   "page",
+		Prompt: "Page",
+		Data: []datum{
+			{Name: "offset", Value: "10", Prompt: "Offset"},
+			{Name: "limit", Value: "10", Prompt: "Limit"},
+		},
+	}
+	if !reflect.DeepEqual(want, c.collection.Queries[0]) {
+		t.Error("Should add a paging query")
+		t.Errorf("Wanted %+v, got %+v", want, c.collection.Queries[0])
+	}
+
+	// Should not be able to page with a limit less than one
+	_, err = NewPagination(*href, Page{})
+	if err != ErrInvalidPage {
+		t.Error("Should not be able to page with a limit less than one")
+		t.Errorf("Wanted %v, got %v", ErrInvalidPage, err)
+	}
+
+	// Should not be able to page with a negative total
+	_, err = NewPagination(*href, Page{Limit: 10, Total: intPtr(-1)})
+	if err != ErrInvalidPage {
+		t.Error("Should not be able to page with a negative total")
+		t.Errorf("Wanted %v, got %v", ErrInvalidPage, err)
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	href := url.URL{Scheme: "http", Host: "example.com", Path: "/friends/", RawQuery: "cursor=b"}
+
+	// Should add links for the cursors that are present
+	pageOpt, err := NewCursorPagination(href, CursorPage{Limit: 20, Next: "c"})
+	if err != nil {
+		t.Errorf("Unexpected error from NewCursorPagination: %v", err)
+	}
+	c, err := NewCollection(pageOpt)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	want := map[string]string{
+		"first": "http://example.com/friends/?limit=20",
+		"next":  "http://example.com/friends/?cursor=c&limit=20",
+	}
+	got := make(map[string]string)
+	for _, l := range c.collection.Links {
+		got[l.Rel] = l.Href
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Error("Should add links for the cursors that are present")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should not be able to attach pagination to an unknown type
+	_, err = NewItem(href, pageOpt)
+	if err != ErrTypeUnknown {
+		t.Error("Should not be able to attach pagination to an unknown type")
+		t.Errorf("Wanted %v, got %v", ErrTypeUnknown, err)
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -6,6 +6,7 @@ import (
 	"net/url"
 
 	"github.com/skriptble/hyper/collection/json"
+	"github.com/skriptble/hyper/collection/json/internal/jsonext"
 )
 
 // ErrTypeUnknown is returned when an option is passed into a New function for a
@@ -27,10 +28,21 @@ type Collection struct {
 
 // encoding/json Marshaler implementation
 func (c Collection) MarshalJSON() ([]byte, error) {
+	if c.collection.validate {
+		err := c.Validate()
+		if err != nil {
+			return nil, err
+		}
+	}
 	document := struct {
-		collection `json:"collection"`
+		C collection `json:"collection"`
 	}{
-		collection: c.collection,
+		C: c.collection,
+	}
+	// The zero Collection, e.g. one returned along with an error, has no
+	// version.
+	if document.C.Version == "" {
+		document.C.Version = cj.V1
 	}
 	return json.Marshal(document)
 }
@@ -43,6 +55,31 @@ type collection struct {
 	Queries  []query    `json:"queries,omitempty"`
 	Template *template  `json:"template,omitempty"`
 	Error    *cjError   `json:"error,omitempty"`
+
+	// Errors holds the errors extension, see NewFieldErrors.
+	Errors []cjFieldError `json:"errors,omitempty"`
+
+	// validate is set by NewValidation
+	validate bool
+	// base is set by NewBase
+	base *rebaser
+	// etag is set by NewETag
+	etag string
+	// unbased is the collection before its hrefs were rewritten against
+	// base, it is used by With to rewrite the hrefs of a new collection.
+	unbased *collection
+
+	ext jsonext.Extensions
+}
+
+func (c *collection) UnmarshalJSON(b []byte) error {
+	type plain collection
+	return jsonext.UnmarshalNumbers(b, (*plain)(c), &c.ext)
+}
+
+func (c collection) MarshalJSON() ([]byte, error) {
+	type plain collection
+	return jsonext.Marshal(plain(c), c.ext)
 }
 
 func NewCollection(opts ...Option) (Collection, error) {
@@ -54,8 +91,54 @@ func NewCollection(opts ...Option) (Collection, error) {
 			return Collection{}, err
 		}
 	}
+	return finish(c), nil
+}
+
+// NewHref creates an Option that sets the href of a collection.
+func NewHref(href url.URL) Option {
+	h := href.String()
+	return func(i interface{}) error {
+		c, ok := i.(*collection)
+		if !ok {
+			return ErrTypeUnknown
+		}
 
-	return Collection{*c}, nil
+		c.Href = h
+		return nil
+	}
+}
+
+// finish returns the Collection for c once all options have been applied,
+// rewriting its hrefs if NewBase was used.
+func finish(c *collection) Collection {
+	if c.base == nil {
+		return Collection{*c}
+	}
+	// rebase replaces the slices holding hrefs rather than changing them,
+	// so a shallow copy keeps the hrefs as they were.
+	unbased := *c
+	c.base.rebase(c)
+	c.unbased = &unbased
+	return Collection{*c}
+}
+
+// collectionOptions combines opts into a single Option that can only be
+// applied to a collection.
+func collectionOptions(opts []Option) Option {
+	return func(i interface{}) error {
+		c, ok := i.(*collection)
+		if !ok {
+			return ErrTypeUnknown
+		}
+
+		for _, opt := range opts {
+			err := opt(c)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 }
 
 type link struct {
@@ -64,6 +147,18 @@ type link struct {
 	Name   string `json:"name,omitempty"`
 	Render string `json:"render,omitempty"`
 	Prompt string `json:"prompt,omitempty"`
+
+	ext jsonext.Extensions
+}
+
+func (l *link) UnmarshalJSON(b []byte) error {
+	type plain link
+	return jsonext.UnmarshalNumbers(b, (*plain)(l), &l.ext)
+}
+
+func (l link) MarshalJSON() ([]byte, error) {
+	type plain link
+	return jsonext.Marshal(plain(l), l.ext)
 }
 
 func NewLink(href url.URL, rel, name, render, prompt string) Option {
@@ -92,6 +187,18 @@ type item struct {
 	Href  string  `json:"href,omitempty"`
 	Data  []datum `json:"data,omitempty"`
 	Links []link  `json:"links,omitempty"`
+
+	ext jsonext.Extensions
+}
+
+func (itm *item) UnmarshalJSON(b []byte) error {
+	type plain item
+	return jsonext.UnmarshalNumbers(b, (*plain)(itm), &itm.ext)
+}
+
+func (itm item) MarshalJSON() ([]byte, error) {
+	type plain item
+	return jsonext.Marshal(plain(itm), itm.ext)
 }
 
 func NewItem(href url.URL, opts ...Option) (Option, error) {
@@ -115,12 +222,50 @@ func NewItem(href url.URL, opts ...Option) (Option, error) {
 	}, nil
 }
 
+// NewItemFilter creates an Option that removes the items of a collection for
+// which keep returns false. The data of each item is given to keep as a map of
+// datum names to values. It is most useful when decorating a collection
+// created by FromConsumer.
+func NewItemFilter(keep func(href string, data map[string]interface{}) bool) Option {
+	return func(i interface{}) error {
+		c, ok := i.(*collection)
+		if !ok {
+			return ErrTypeUnknown
+		}
+
+		items := c.Items[:0:0]
+		for _, itm := range c.Items {
+			data := make(map[string]interface{}, len(itm.Data))
+			for _, d := range itm.Data {
+				data[d.Name] = d.Value
+			}
+			if keep(itm.Href, data) {
+				items = append(items, itm)
+			}
+		}
+		c.Items = items
+		return nil
+	}
+}
+
 type query struct {
 	Href   string  `json:"href"`
 	Rel    string  `json:"rel"`
 	Name   string  `json:"name,omitempty"`
 	Prompt string  `json:"prompt,omitempty"`
 	Data   []datum `json:"data,omitempty"`
+
+	ext jsonext.Extensions
+}
+
+func (q *query) UnmarshalJSON(b []byte) error {
+	type plain query
+	return jsonext.UnmarshalNumbers(b, (*plain)(q), &q.ext)
+}
+
+func (q query) MarshalJSON() ([]byte, error) {
+	type plain query
+	return jsonext.Marshal(plain(q), q.ext)
 }
 
 func NewQuery(href url.URL, rel, name, prompt string, opts ...Option) (Option, error) {
@@ -150,6 +295,18 @@ func NewQuery(href url.URL, rel, name, prompt string, opts ...Option) (Option, e
 
 type template struct {
 	Data []datum `json:"data"`
+
+	ext jsonext.Extensions
+}
+
+func (t *template) UnmarshalJSON(b []byte) error {
+	type plain template
+	return jsonext.UnmarshalNumbers(b, (*plain)(t), &t.ext)
+}
+
+func (t template) MarshalJSON() ([]byte, error) {
+	type plain template
+	return jsonext.Marshal(plain(t), t.ext)
 }
 
 func NewTemplate(opts ...Option) (Option, error) {
@@ -172,18 +329,34 @@ func NewTemplate(opts ...Option) (Option, error) {
 	}, nil
 }
 
+// datum holds its value as an interface{} since the value of a datum can be
+// any JSON value, not only a string.
 type datum struct {
-	Name   string `json:"name"`
-	Value  string `json:"value,omitempty"`
-	Prompt string `json:"prompt,omitempty"`
+	Name   string      `json:"name"`
+	Value  interface{} `json:"value,omitempty"`
+	Prompt string      `json:"prompt,omitempty"`
+
+	ext jsonext.Extensions
+}
+
+func (d *datum) UnmarshalJSON(b []byte) error {
+	type plain datum
+	return jsonext.UnmarshalNumbers(b, (*plain)(d), &d.ext)
+}
+
+func (d datum) MarshalJSON() ([]byte, error) {
+	type plain datum
+	return jsonext.Marshal(plain(d), d.ext)
 }
 
 func NewDatum(name, value, prompt string) Option {
 	d := datum{
 		Name:   name,
-		Value:  value,
 		Prompt: prompt,
 	}
+	if value != "" {
+		d.Value = value
+	}
 	return func(i interface{}) error {
 		switch t := i.(type) {
 		case *template:
@@ -203,6 +376,18 @@ type cjError struct {
 	Title   string `json:"title,omitempty"`
 	Code    string `json:"code,omitempty"`
 	Message string `json:"message,omitempty"`
+
+	ext jsonext.Extensions
+}
+
+func (e *cjError) UnmarshalJSON(b []byte) error {
+	type plain cjError
+	return jsonext.UnmarshalNumbers(b, (*plain)(e), &e.ext)
+}
+
+func (e cjError) MarshalJSON() ([]byte, error) {
+	type plain cjError
+	return jsonext.Marshal(plain(e), e.ext)
 }
 
 func NewError(title, code, message string) Option {
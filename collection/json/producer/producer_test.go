@@ -33,6 +33,16 @@ func TestCollection(t *testing.T) {
 		t.Error("Should not be able to attach incorrect option to collection")
 		t.Errorf("Wanted %v, got %v", ErrTypeUnknown, err)
 	}
+
+	// Should be able to set the href of a collection
+	c, err = NewCollection(NewHref(url.URL{Scheme: "http", Host: "example.com", Path: "/friends/"}))
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if c.collection.Href != "http://example.com/friends/" {
+		t.Error("Should be able to set the href of a collection")
+		t.Errorf("Wanted %v, got %v", "http://example.com/friends/", c.collection.Href)
+	}
 }
 
 func TestError(t *testing.T) {
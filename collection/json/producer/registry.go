@@ -0,0 +1,198 @@
+package producer
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// ErrInvalidTarget is returned by RegisterAs when the target is not a pointer
+// to a type implementing error.
+var ErrInvalidTarget = errors.New("producer: target must be a pointer to a type implementing error")
+
+// ErrorMapping describes how an error is represented in a C+J error document.
+type ErrorMapping struct {
+	// Status is the HTTP status code of the response.
+	Status int
+	// Title and Code are the title and code of the C+J error. If empty they
+	// default to the status text and the status code.
+	Title string
+	Code  string
+	// Message is a text/template for the message of the C+J error. It is
+	// executed with the matched error as its data, e.g. "{{.Name}} not
+	// found" for a matched *NotFoundError with a Name field.
+	Message string
+}
+
+// Registry maps Go errors to C+J error documents. Errors are matched against
+// the registered errors in the order they were registered, using errors.Is
+// for errors registered with Register and errors.As for types registered with
+// RegisterAs. An error that matches nothing is represented as a 500 without
+// exposing its text. A *StatusError is always represented by its own fields
+// and FieldErrors are always represented as a 400 with the errors extension.
+//
+// A Registry is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	entries []registryEntry
+}
+
+type registryEntry struct {
+	match   func(error) (interface{}, bool)
+	mapping ErrorMapping
+	message *texttemplate.Template
+}
+
+// DefaultRegistry is the Registry used by HandlerFunc. It maps the errors
+// returned by Decoder to 400 and 413 responses, and ErrPreconditionFailed to
+// 412 responses. The messages it serves are fixed, the text of the errors is
+// never exposed. The details of decoding errors for a single datum are served
+// with the errors extension, see FieldErrors.
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	defaults := []struct {
+		target  error
+		mapping ErrorMapping
+	}{
+		{ErrInvalidBody, ErrorMapping{Status: http.StatusBadRequest, Message: "The submitted template is not valid."}},
+		{ErrUnknownField, ErrorMapping{Status: http.StatusBadRequest, Message: "The submitted template has an unknown field."}},
+		{ErrMissingField, ErrorMapping{Status: http.StatusBadRequest, Message: "The submitted template is missing a field."}},
+		{ErrDuplicateField, ErrorMapping{Status: http.StatusBadRequest, Message: "The submitted template has a duplicate field."}},
+		{ErrInvalidValue, ErrorMapping{Status: http.StatusBadRequest, Message: "A submitted value is not valid."}},
+		{ErrTooLarge, ErrorMapping{Status: http.StatusRequestEntityTooLarge, Message: "The submitted template is too large."}},
+		{ErrPreconditionFailed, ErrorMapping{
+			Status:  http.StatusPreconditionFailed,
+			Message: "The resource has changed since it was retrieved.",
+		}},
+	}
+	for _, d := range defaults {
+		err := DefaultRegistry.Register(d.target, d.mapping)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return new(Registry)
+}
+
+// Register maps errors matching target, as reported by errors.Is, to m. An
+// error is returned if the message template of m cannot be parsed.
+func (reg *Registry) Register(target error, m ErrorMapping) error {
+	return reg.add(m, func(err error) (interface{}, bool) {
+		return err, errors.Is(err, target)
+	})
+}
+
+// RegisterAs maps errors matching the type target points to, as reported by
+// errors.As, to m. As with errors.As, target is a pointer to the error type.
+// The message template of m is executed with the matched error. An error is
+// returned if target is not a pointer to a type implementing error, or if the
+// message template cannot be parsed.
+//
+//	reg.RegisterAs(new(*NotFoundError), m)
+func (reg *Registry) RegisterAs(target interface{}, m ErrorMapping) error {
+	typ := reflect.TypeOf(target)
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	if typ == nil || typ.Kind() != reflect.Ptr || !typ.Elem().Implements(errorType) {
+		return ErrInvalidTarget
+	}
+	return reg.add(m, func(err error) (interface{}, bool) {
+		ptr := reflect.New(typ.Elem())
+		if !errors.As(err, ptr.Interface()) {
+			return nil, false
+		}
+		return ptr.Elem().Interface(), true
+	})
+}
+
+func (reg *Registry) add(m ErrorMapping, match func(error) (interface{}, bool)) error {
+	e := registryEntry{match: match, mapping: m}
+	if m.Message != "" {
+		tmpl, err := texttemplate.New("message").Parse(m.Message)
+		if err != nil {
+			return err
+		}
+		e.message = tmpl
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.entries = append(reg.entries, e)
+	return nil
+}
+
+// Lookup returns the status code, title, code, and message used to represent
+// err.
+func (reg *Registry) Lookup(err error) ErrorMapping {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return defaults(ErrorMapping{Status: se.Status, Title: se.Title, Code: se.Code, Message: se.Message})
+	}
+	var fe FieldErrors
+	if errors.As(err, &fe) {
+		return defaults(ErrorMapping{Status: http.StatusBadRequest, Message: "One or more fields are invalid."})
+	}
+
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	for _, e := range reg.entries {
+		data, ok := e.match(err)
+		if !ok {
+			continue
+		}
+		m := e.mapping
+		m.Message = ""
+		if e.message != nil {
+			var sb strings.Builder
+			// A message that fails to execute is left out rather than
+			// risk exposing a partial message.
+			if e.message.Execute(&sb, data) == nil {
+				m.Message = sb.String()
+			}
+		}
+		return defaults(m)
+	}
+	return defaults(ErrorMapping{Status: http.StatusInternalServerError})
+}
+
+// Collection returns the C+J error document and status code representing err
+// in response to r.
+func (reg *Registry) Collection(r *http.Request, err error) (Collection, int) {
+	m := reg.Lookup(err)
+	opts := []Option{NewError(m.Title, m.Code, m.Message)}
+	var fe FieldErrors
+	if errors.As(err, &fe) {
+		opts = append(opts, NewFieldErrors(fe...))
+	}
+	c, _ := NewCollection(opts...)
+	c.collection.Href = r.URL.String()
+	return c, m.Status
+}
+
+// Handler returns an http.Handler that serves fn like HandlerFunc does, but
+// represents errors using reg.
+func (reg *Registry) Handler(fn HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serve(reg, fn, w, r)
+	})
+}
+
+func defaults(m ErrorMapping) ErrorMapping {
+	if m.Status == 0 {
+		m.Status = http.StatusInternalServerError
+	}
+	if m.Title == "" {
+		m.Title = http.StatusText(m.Status)
+	}
+	if m.Code == "" {
+		m.Code = strconv.Itoa(m.Status)
+	}
+	return m
+}
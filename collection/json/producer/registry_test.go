@@ -0,0 +1,121 @@
+package producer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errGone = errors.New("friend was deleted")
+
+type notFoundError struct {
+	Name string
+}
+
+func (e *notFoundError) Error() string { return "no friend named " + e.Name }
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	err := reg.Register(errGone, ErrorMapping{Status: http.StatusGone, Code: "gone"})
+	if err != nil {
+		t.Errorf("Unexpected error from Register: %v", err)
+	}
+	err = reg.RegisterAs(new(*notFoundError), ErrorMapping{
+		Status:  http.StatusNotFound,
+		Message: "{{.Name}} is not a friend",
+	})
+	if err != nil {
+		t.Errorf("Unexpected error from RegisterAs: %v", err)
+	}
+
+	tests := []struct {
+		err  error
+		want ErrorMapping
+	}{
+		{
+			fmt.Errorf("loading jdoe: %w", errGone),
+			ErrorMapping{Status: 410, Title: "Gone", Code: "gone"},
+		},
+		{
+			fmt.Errorf("loading jdoe: %w", &notFoundError{Name: "jdoe"}),
+			ErrorMapping{Status: 404, Title: "Not Found", Code: "404", Message: "jdoe is not a friend"},
+		},
+		{
+			&StatusError{Status: http.StatusConflict, Message: "already friends"},
+			ErrorMapping{Status: 409, Title: "Conflict", Code: "409", Message: "already friends"},
+		},
+		{
+			errors.New("dial tcp 10.0.0.1:5432: connection refused"),
+			ErrorMapping{Status: 500, Title: "Internal Server Error", Code: "500"},
+		},
+	}
+	for _, test := range tests {
+		got := reg.Lookup(test.err)
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("Looking up %v", test.err)
+			t.Errorf("Wanted %+v, got %+v", test.want, got)
+		}
+	}
+
+	// Should not be able to register a target that is not a pointer to an error
+	err = reg.RegisterAs(notFoundError{}, ErrorMapping{})
+	if err != ErrInvalidTarget {
+		t.Error("Should not be able to register a target that is not a pointer to an error")
+		t.Errorf("Wanted %v, got %v", ErrInvalidTarget, err)
+	}
+
+	// Should be able to serve errors using a registry
+	h := reg.Handler(func(r *http.Request) (Collection, error) {
+		return Collection{}, &notFoundError{Name: "jdoe"}
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/friends/jdoe", nil))
+	want := `{"collection":{"version":"1.0","href":"/friends/jdoe","error":{"title":"Not Found","code":"404","message":"jdoe is not a friend"}}}`
+	if w.Code != http.StatusNotFound || w.Body.String() != want {
+		t.Error("Should be able to serve errors using a registry")
+		t.Errorf("Wanted 404 %s, got %d %s", want, w.Code, w.Body)
+	}
+
+	// Should map decoding errors to bad requests by default
+	got := DefaultRegistry.Lookup(fmt.Errorf("%w: %q", ErrUnknownField, "email"))
+	if got.Status != http.StatusBadRequest || got.Message != "The submitted template has an unknown field." {
+		t.Error("Should map decoding errors to bad requests by default")
+		t.Errorf("Wanted 400, got %+v", got)
+	}
+	got = DefaultRegistry.Lookup(fmt.Errorf("%w: %w", ErrInvalidBody, errors.New("unexpected EOF")))
+	if got.Status != http.StatusBadRequest || got.Message != "The submitted template is not valid." {
+		t.Error("Should map decoding errors to bad requests by default")
+		t.Errorf("Wanted 400, got %+v", got)
+	}
+
+	// Should not expose the text of decoding errors
+	var v struct {
+		Placed time.Time `cj:"datum,Placed,placed"`
+	}
+	tmpl, err := NewTemplateFrom(v)
+	if err != nil {
+		t.Errorf("Unexpected error from NewTemplateFrom: %v", err)
+	}
+	dec, err := NewDecoder(tmpl, 0)
+	if err != nil {
+		t.Errorf("Unexpected error from NewDecoder: %v", err)
+	}
+	err = dec.Decode(strings.NewReader(`{"template":{"data":[{"name":"placed","value":"yesterday"}]}}`), &v)
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Wanted %v, got %v", ErrInvalidValue, err)
+	}
+	c, status := DefaultRegistry.Collection(httptest.NewRequest(http.MethodPut, "/orders/1", nil), err)
+	b, _ := c.MarshalJSON()
+	want = `{"collection":{"version":"1.0","href":"/orders/1",` +
+		`"error":{"title":"Bad Request","code":"400","message":"One or more fields are invalid."},` +
+		`"errors":[{"name":"placed","code":"invalid","message":"This value is not valid."}]}}`
+	if status != http.StatusBadRequest || string(b) != want {
+		t.Error("Should not expose the text of decoding errors")
+		t.Errorf("Wanted 400 %s, got %d %s", want, status, b)
+	}
+}
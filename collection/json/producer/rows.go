@@ -0,0 +1,157 @@
+package producer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/skriptble/hyper/uritemplate"
+)
+
+// RowLinker generates a link for the item of a row. The row is given as a map
+// of column names to values, as stored in the data of the item. The returned
+// Option is applied to the item, usually it is created by NewLink or
+// NewExpandedLink. A nil Option adds no link.
+type RowLinker func(row map[string]interface{}) (Option, error)
+
+// null is the value of a datum whose column is NULL.
+var null = json.RawMessage("null")
+
+// NewRows creates an Option that adds each row of rows to a collection as an
+// item, see NewRowIterator. The rows are read, but not closed, when NewRows is
+// called.
+func NewRows(rows *sql.Rows, href string, prompts map[string]string, links ...RowLinker) (Option, error) {
+	it, err := NewRowIterator(rows, href, prompts, links...)
+	if err != nil {
+		return nil, err
+	}
+	var opts []Option
+	for it.Next() {
+		opt, err := it.Item()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return collectionOptions(opts), nil
+}
+
+// NewRowIterator returns an ItemIterator that turns each row of rows into an
+// item, for use with an Encoder. Each column becomes a datum named after the
+// column, with the prompt given for the column in prompts. Values keep the
+// type they are scanned into by the driver, except that []byte becomes a
+// string and NULL becomes null.
+//
+// The href of each item is the expansion of the RFC 6570 URI Template href
+// with the values of the row, e.g. "/orders/{id}". Each of links is called with
+// every row to add links to its item. The iterator does not close rows.
+func NewRowIterator(rows *sql.Rows, href string, prompts map[string]string, links ...RowLinker) (ItemIterator, error) {
+	tmpl, err := uritemplate.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	return &rowIterator{rows: rows, cols: cols, href: tmpl, prompts: prompts, links: links}, nil
+}
+
+type rowIterator struct {
+	rows    *sql.Rows
+	cols    []string
+	href    uritemplate.Template
+	prompts map[string]string
+	links   []RowLinker
+
+	cur Option
+	err error
+}
+
+func (it *rowIterator) Next() bool {
+	if it.err != nil || !it.rows.Next() {
+		return false
+	}
+	it.cur, it.err = it.item()
+	return true
+}
+
+func (it *rowIterator) Item() (Option, error) { return it.cur, it.err }
+
+func (it *rowIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.rows.Err()
+}
+
+// item scans the current row into an item.
+func (it *rowIterator) item() (Option, error) {
+	vals := make([]interface{}, len(it.cols))
+	ptrs := make([]interface{}, len(it.cols))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	err := it.rows.Scan(ptrs...)
+	if err != nil {
+		return nil, err
+	}
+
+	itm := item{Data: make([]datum, len(it.cols))}
+	row := make(map[string]interface{}, len(it.cols))
+	vars := make(map[string]interface{}, len(it.cols))
+	for i, col := range it.cols {
+		val := vals[i]
+		if b, ok := val.([]byte); ok {
+			val = string(b)
+		}
+		row[col] = val
+		d := datum{Name: col, Value: val, Prompt: it.prompts[col]}
+		switch v := val.(type) {
+		case nil:
+			d.Value = null
+		case time.Time:
+			vars[col] = v.Format(time.RFC3339Nano)
+		default:
+			vars[col] = v
+		}
+		itm.Data[i] = d
+	}
+
+	href, err := it.href.Expand(vars)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+	itm.Href = u.String()
+	for _, link := range it.links {
+		opt, err := link(row)
+		if err != nil {
+			return nil, err
+		}
+		if opt == nil {
+			continue
+		}
+		err = opt(&itm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return func(i interface{}) error {
+		c, ok := i.(*collection)
+		if !ok {
+			return ErrTypeUnknown
+		}
+
+		c.Items = append(c.Items, itm)
+		return nil
+	}, nil
+}
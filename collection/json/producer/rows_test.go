@@ -0,0 +1,123 @@
+package producer
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// fakeDriver is a database/sql driver whose queries always return the rows of
+// fakeRows.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+var since = time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC)
+
+type fakeRows struct{ n int }
+
+func (r *fakeRows) Columns() []string { return []string{"id", "name", "total", "placed"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	rows := [][]driver.Value{
+		{int64(1), []byte("J. Doe"), 12.5, since},
+		{int64(2), nil, int64(3), nil},
+	}
+	if r.n >= len(rows) {
+		return io.EOF
+	}
+	copy(dest, rows[r.n])
+	r.n++
+	return nil
+}
+
+func init() {
+	sql.Register("producer-fake", fakeDriver{})
+}
+
+func TestRows(t *testing.T) {
+	db, err := sql.Open("producer-fake", "")
+	if err != nil {
+		t.Fatalf("Unexpected error from sql.Open: %v", err)
+	}
+	defer db.Close()
+	rows, err := db.Query("SELECT id, name, total, placed FROM orders")
+	if err != nil {
+		t.Fatalf("Unexpected error from Query: %v", err)
+	}
+	defer rows.Close()
+
+	customer := func(row map[string]interface{}) (Option, error) {
+		if row["name"] == nil {
+			return nil, nil
+		}
+		return NewExpandedLink("/customers/{name}", row, "customer", "", "", "Customer")
+	}
+	opt, err := NewRows(rows, "/orders/{id}", map[string]string{"total": "Total"}, customer)
+	if err != nil {
+		t.Errorf("Unexpected error from NewRows: %v", err)
+	}
+	c, err := NewCollection(opt)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+
+	// Should be able to build items from rows
+	b, err := json.Marshal(c.collection.Items)
+	if err != nil {
+		t.Errorf("Unexpected error from json.Marshal: %v", err)
+	}
+	want := `[{"href":"/orders/1","data":[` +
+		`{"name":"id","value":1},{"name":"name","value":"J. Doe"},` +
+		`{"name":"total","value":12.5,"prompt":"Total"},{"name":"placed","value":"2015-04-01T00:00:00Z"}],` +
+		`"links":[{"href":"/customers/J.%20Doe","rel":"customer","prompt":"Customer"}]},` +
+		`{"href":"/orders/2","data":[` +
+		`{"name":"id","value":2},{"name":"name","value":null},` +
+		`{"name":"total","value":3,"prompt":"Total"},{"name":"placed","value":null}]}]`
+	if string(b) != want {
+		t.Error("Should be able to build items from rows")
+		t.Errorf("Wanted %v, got %s", want, b)
+	}
+
+	// Should not be able to use an invalid href template
+	_, err = NewRowIterator(rows, "/orders/{id", nil)
+	if err == nil {
+		t.Error("Should not be able to use an invalid href template")
+	}
+
+	// Should return the error of a link generator
+	rows, err = db.Query("SELECT id, name, total, placed FROM orders")
+	if err != nil {
+		t.Fatalf("Unexpected error from Query: %v", err)
+	}
+	defer rows.Close()
+	failing := func(row map[string]interface{}) (Option, error) {
+		return nil, errors.New("no link")
+	}
+	_, err = NewRows(rows, "/orders/{id}", nil, failing)
+	if err == nil || err.Error() != "no link" {
+		t.Error("Should return the error of a link generator")
+		t.Errorf("Wanted %v, got %v", "no link", err)
+	}
+}
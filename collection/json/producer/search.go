@@ -0,0 +1,385 @@
+package producer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilterOp is the comparison a Filter makes between the value of a datum and
+// the value of its query parameter. A time is compared with an RFC 3339 time
+// or a date, a date covers the whole day, e.g. Max with 2015-04-04 keeps
+// every time on that day.
+type FilterOp int
+
+// These constants are the comparisons a Filter can make.
+const (
+	_ FilterOp = iota
+	// Equal keeps items whose datum equals one of the parameter values.
+	Equal
+	// Prefix keeps items whose datum starts with one of the parameter values.
+	Prefix
+	// Min keeps items whose datum is greater than or equal to the parameter.
+	Min
+	// Max keeps items whose datum is less than or equal to the parameter.
+	Max
+)
+
+// Filter declares a parameter of a SearchQuery that filters items by the value
+// of one of their data.
+type Filter struct {
+	// Param is the name of the query parameter.
+	Param string
+	// Field is the name of the datum that is compared, it defaults to Param.
+	Field  string
+	Op     FilterOp
+	Prompt string
+}
+
+// SearchQuery declares a query that NewSearch answers from the items of an
+// in-memory collection.
+type SearchQuery struct {
+	// Href, Rel, Name, and Prompt are those of the advertised query.
+	Href   url.URL
+	Rel    string
+	Name   string
+	Prompt string
+
+	Filters []Filter
+	// Sorts are the names of the data items can be sorted by. The sort
+	// parameter holds a comma separated list of names, a name prefixed by
+	// "-" sorts in descending order, e.g. "sort=-total,name".
+	Sorts     []string
+	SortParam string
+
+	// Limit is the default number of items in a page. If it is zero items
+	// are not paged. MaxLimit caps the limit a client can ask for.
+	Limit       int
+	MaxLimit    int
+	OffsetParam string
+	LimitParam  string
+}
+
+// NewSearchQuery creates an Option that advertises q as a query of a
+// collection, with a datum for each of its filters and a datum for sorting if
+// q has sorts.
+func NewSearchQuery(q SearchQuery) (Option, error) {
+	var opts []Option
+	for _, f := range q.Filters {
+		opts = append(opts, NewDatum(f.Param, "", f.Prompt))
+	}
+	if len(q.Sorts) > 0 {
+		opts = append(opts, NewDatum(defaultString(q.SortParam, "sort"), "", "Sort"))
+	}
+	return NewQuery(q.Href, q.Rel, q.Name, q.Prompt, opts...)
+}
+
+// NewSearch creates an Option that answers the query q from v. It adds the
+// items of v that match the query parameters of href, the URL of the request,
+// to a collection, sorted and paged as requested, along with the paging links
+// and query from NewPagination. v is either a slice of structs, marshaled into
+// items as by NewItems, or a []Option adding items, e.g. created by NewItem.
+// Only the items added by such Options are used.
+//
+// A parameter value that cannot be compared with the datum it filters, an
+// unknown sort, or an invalid offset or limit returns an error wrapping
+// ErrInvalidValue.
+func NewSearch(q SearchQuery, href url.URL, v interface{}) (Option, error) {
+	items, err := searchItems(v)
+	if err != nil {
+		return nil, err
+	}
+	values := href.Query()
+
+	for _, f := range q.Filters {
+		params := values[f.Param]
+		if len(params) == 0 || params[0] == "" {
+			continue
+		}
+		matched := items[:0:0]
+		for _, itm := range items {
+			ok, err := f.match(itm, params)
+			if err != nil {
+				return nil, newDatumError(ErrInvalidValue, f.Param, err)
+			}
+			if ok {
+				matched = append(matched, itm)
+			}
+		}
+		items = matched
+	}
+
+	sortParam := defaultString(q.SortParam, "sort")
+	if params := values[sortParam]; len(params) > 0 {
+		err := q.sort(items, strings.Split(strings.Join(params, ","), ","))
+		if err != nil {
+			return nil, newDatumError(ErrInvalidValue, sortParam, err)
+		}
+	}
+
+	opts := []Option{}
+	if q.Limit > 0 {
+		p, err := q.page(values, len(items))
+		if err != nil {
+			return nil, err
+		}
+		pageOpt, err := NewPagination(href, p)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, pageOpt)
+		if p.Offset < len(items) {
+			items = items[p.Offset:]
+		} else {
+			items = nil
+		}
+		if len(items) > p.Limit {
+			items = items[:p.Limit]
+		}
+	}
+
+	itemsOpt := func(i interface{}) error {
+		c, ok := i.(*collection)
+		if !ok {
+			return ErrTypeUnknown
+		}
+
+		c.Items = append(c.Items, items...)
+		return nil
+	}
+	return collectionOptions(append([]Option{itemsOpt}, opts...)), nil
+}
+
+// searchItems returns the items added by v, see NewSearch.
+func searchItems(v interface{}) ([]item, error) {
+	opts, ok := v.([]Option)
+	if !ok {
+		opt, err := NewItems(v)
+		if err != nil {
+			return nil, err
+		}
+		opts = []Option{opt}
+	}
+	scratch := new(collection)
+	for _, opt := range opts {
+		err := opt(scratch)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return scratch.Items, nil
+}
+
+// page returns the page requested by values for a listing of total items.
+func (q SearchQuery) page(values url.Values, total int) (Page, error) {
+	p := Page{
+		Limit:       q.Limit,
+		Total:       &total,
+		OffsetParam: defaultString(q.OffsetParam, "offset"),
+		LimitParam:  defaultString(q.LimitParam, "limit"),
+	}
+	if s := values.Get(p.OffsetParam); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return p, newDatumError(ErrInvalidValue, p.OffsetParam, fmt.Errorf("%q", s))
+		}
+		p.Offset = n
+	}
+	if s := values.Get(p.LimitParam); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return p, newDatumError(ErrInvalidValue, p.LimitParam, fmt.Errorf("%q", s))
+		}
+		p.Limit = n
+	}
+	if q.MaxLimit > 0 && p.Limit > q.MaxLimit {
+		p.Limit = q.MaxLimit
+	}
+	return p, nil
+}
+
+// sort sorts items by the data named in by, which must be sorts of q.
+func (q SearchQuery) sort(items []item, by []string) error {
+	type key struct {
+		name string
+		desc bool
+	}
+	keys := make([]key, 0, len(by))
+	for _, name := range by {
+		k := key{name: strings.TrimPrefix(name, "-"), desc: strings.HasPrefix(name, "-")}
+		known := false
+		for _, s := range q.Sorts {
+			known = known || s == k.name
+		}
+		if !known {
+			return fmt.Errorf("unknown sort %q", k.name)
+		}
+		keys = append(keys, k)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, k := range keys {
+			a, _ := datumValue(items[i], k.name)
+			b, _ := datumValue(items[j], k.name)
+			c := compareValues(a, b)
+			if c == 0 {
+				continue
+			}
+			return (c < 0) != k.desc
+		}
+		return false
+	})
+	return nil
+}
+
+// match reports whether the datum of itm filtered by f matches params.
+func (f Filter) match(itm item, params []string) (bool, error) {
+	val, ok := datumValue(itm, defaultString(f.Field, f.Param))
+	if !ok || val == nil {
+		return false, nil
+	}
+	switch f.Op {
+	case Prefix:
+		for _, p := range params {
+			if strings.HasPrefix(fmt.Sprint(val), p) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case Min, Max:
+		c, err := compareParam(val, params[0])
+		if err != nil {
+			return false, err
+		}
+		return (f.Op == Min && c >= 0) || (f.Op == Max && c <= 0), nil
+	default:
+		for _, p := range params {
+			c, err := compareParam(val, p)
+			if err != nil {
+				return false, err
+			}
+			if c == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// datumValue returns the value of the datum of itm named name.
+func datumValue(itm item, name string) (interface{}, bool) {
+	for _, d := range itm.Data {
+		if d.Name == name {
+			return d.Value, true
+		}
+	}
+	return nil, false
+}
+
+// compareParam compares the value of a datum with s, the value of a query
+// parameter, converting s to the type of the value.
+func compareParam(v interface{}, s string) (int, error) {
+	switch v := v.(type) {
+	case string:
+		return strings.Compare(v, s), nil
+	case bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return 0, err
+		}
+		return compareValues(v, b), nil
+	case time.Time:
+		t, dateOnly, err := parseTime(s)
+		if err != nil {
+			return 0, err
+		}
+		if dateOnly {
+			// A date covers the whole day, so only the date of v is
+			// compared.
+			v = v.UTC().Truncate(24 * time.Hour)
+		}
+		return v.Compare(t), nil
+	}
+	if f, ok := toFloat(v); ok {
+		p, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compareValues(f, p), nil
+	}
+	return strings.Compare(fmt.Sprint(v), s), nil
+}
+
+// compareValues compares the values of two data. Missing values sort first.
+func compareValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	switch x := a.(type) {
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case !x:
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// toFloat returns v as a float64 if it is a number.
+func toFloat(v interface{}) (float64, bool) {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// parseTime parses s as an RFC 3339 time or a date, in which case dateOnly
+// is true and t is midnight UTC of the date.
+func parseTime(s string) (t time.Time, dateOnly bool, err error) {
+	t, err = time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t, false, nil
+	}
+	t, err = time.Parse("2006-01-02", s)
+	return t, true, err
+}
@@ -0,0 +1,182 @@
+package producer
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type order struct {
+	Href   string    `cj:"href"`
+	Status string    `cj:"datum,Status,status"`
+	Total  float64   `cj:"datum,Total,total"`
+	Placed time.Time `cj:"datum,Placed,placed"`
+}
+
+var orders = []order{
+	{"/orders/1", "open", 12.5, time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC)},
+	{"/orders/2", "closed", 40, time.Date(2015, 4, 2, 0, 0, 0, 0, time.UTC)},
+	{"/orders/3", "open", 7, time.Date(2015, 4, 3, 0, 0, 0, 0, time.UTC)},
+	{"/orders/4", "on-hold", 99, time.Date(2015, 4, 4, 0, 0, 0, 0, time.UTC)},
+	{"/orders/5", "open", 40, time.Date(2015, 4, 5, 0, 0, 0, 0, time.UTC)},
+}
+
+var orderQuery = SearchQuery{
+	Href:   url.URL{Path: "/orders/"},
+	Rel:    "search",
+	Name:   "orders",
+	Prompt: "Search Orders",
+	Filters: []Filter{
+		{Param: "status", Op: Equal, Prompt: "Status"},
+		{Param: "status-prefix", Field: "status", Op: Prefix},
+		{Param: "min-total", Field: "total", Op: Min},
+		{Param: "max-total", Field: "total", Op: Max},
+		{Param: "since", Field: "placed", Op: Min},
+	},
+	Sorts:    []string{"total", "placed"},
+	Limit:    2,
+	MaxLimit: 3,
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		query string
+		hrefs []string
+		links []string
+	}{
+		{"", []string{"/orders/1", "/orders/2"},
+			[]string{"/orders/?limit=2&offset=0", "/orders/?limit=2&offset=2", "/orders/?limit=2&offset=4"}},
+		{"status=open&status=on-hold&sort=-total,placed&limit=5", []string{"/orders/4", "/orders/5", "/orders/1"},
+			[]string{"/orders/?limit=3&offset=0&sort=-total%2Cplaced&status=open&status=on-hold",
+				"/orders/?limit=3&offset=3&sort=-total%2Cplaced&status=open&status=on-hold",
+				"/orders/?limit=3&offset=3&sort=-total%2Cplaced&status=open&status=on-hold"}},
+		{"status-prefix=o&min-total=10&max-total=40&offset=1", []string{"/orders/5"},
+			[]string{"/orders/?limit=2&max-total=40&min-total=10&offset=0&status-prefix=o",
+				"/orders/?limit=1&max-total=40&min-total=10&offset=0&status-prefix=o",
+				"/orders/?limit=2&max-total=40&min-total=10&offset=1&status-prefix=o"}},
+		{"since=2015-04-04&sort=total", []string{"/orders/5", "/orders/4"},
+			[]string{"/orders/?limit=2&offset=0&since=2015-04-04&sort=total",
+				"/orders/?limit=2&offset=0&since=2015-04-04&sort=total"}},
+	}
+	for _, test := range tests {
+		href := url.URL{Path: "/orders/", RawQuery: test.query}
+		opt, err := NewSearch(orderQuery, href, orders)
+		if err != nil {
+			t.Errorf("Unexpected error from NewSearch for %q: %v", test.query, err)
+			continue
+		}
+		c, err := NewCollection(opt)
+		if err != nil {
+			t.Errorf("Unexpected error from NewCollection: %v", err)
+			continue
+		}
+		var hrefs, links []string
+		for _, itm := range c.collection.Items {
+			hrefs = append(hrefs, itm.Href)
+		}
+		for _, l := range c.collection.Links {
+			links = append(links, l.Href)
+		}
+		if !reflect.DeepEqual(test.hrefs, hrefs) || !reflect.DeepEqual(test.links, links) {
+			t.Errorf("Should answer the query %q", test.query)
+			t.Errorf("Wanted %v %v, got %v %v", test.hrefs, test.links, hrefs, links)
+		}
+	}
+
+	// Should be able to search items created by Options
+	var opts []Option
+	for _, o := range orders {
+		opt, err := NewItem(url.URL{Path: o.Href}, NewDatum("status", o.Status, ""))
+		if err != nil {
+			t.Errorf("Unexpected error from NewItem: %v", err)
+		}
+		opts = append(opts, opt)
+	}
+	opt, err := NewSearch(SearchQuery{Filters: orderQuery.Filters}, url.URL{RawQuery: "status=closed"}, opts)
+	if err != nil {
+		t.Errorf("Unexpected error from NewSearch: %v", err)
+	}
+	c, err := NewCollection(opt)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	if len(c.collection.Items) != 1 || c.collection.Items[0].Href != "/orders/2" || c.collection.Links != nil {
+		t.Error("Should be able to search items created by Options")
+		t.Errorf("Wanted %v, got %+v", "/orders/2", c.collection)
+	}
+
+	// Should compare times with dates by their date
+	placed := []order{
+		{"/orders/6", "open", 1, time.Date(2015, 4, 3, 23, 59, 0, 0, time.UTC)},
+		{"/orders/7", "open", 1, time.Date(2015, 4, 4, 15, 0, 0, 0, time.UTC)},
+		{"/orders/8", "open", 1, time.Date(2015, 4, 5, 0, 0, 0, 0, time.UTC)},
+	}
+	dateQuery := SearchQuery{Filters: []Filter{
+		{Param: "since", Field: "placed", Op: Min},
+		{Param: "until", Field: "placed", Op: Max},
+		{Param: "on", Field: "placed", Op: Equal},
+	}}
+	dateTests := []struct {
+		query string
+		hrefs []string
+	}{
+		{"until=2015-04-04", []string{"/orders/6", "/orders/7"}},
+		{"since=2015-04-04", []string{"/orders/7", "/orders/8"}},
+		{"on=2015-04-04", []string{"/orders/7"}},
+		{"until=2015-04-04T12:00:00Z", []string{"/orders/6"}},
+	}
+	for _, test := range dateTests {
+		opt, err := NewSearch(dateQuery, url.URL{RawQuery: test.query}, placed)
+		if err != nil {
+			t.Errorf("Unexpected error from NewSearch for %q: %v", test.query, err)
+			continue
+		}
+		c, err := NewCollection(opt)
+		if err != nil {
+			t.Errorf("Unexpected error from NewCollection: %v", err)
+		}
+		var hrefs []string
+		for _, itm := range c.collection.Items {
+			hrefs = append(hrefs, itm.Href)
+		}
+		if !reflect.DeepEqual(test.hrefs, hrefs) {
+			t.Error("Should compare times with dates by their date")
+			t.Errorf("Wanted %v, got %v for %q", test.hrefs, hrefs, test.query)
+		}
+	}
+
+	// Should reject invalid parameters
+	for _, query := range []string{"min-total=ten", "since=yesterday", "sort=status", "offset=-1", "limit=0"} {
+		_, err := NewSearch(orderQuery, url.URL{RawQuery: query}, orders)
+		if !errors.Is(err, ErrInvalidValue) {
+			t.Error("Should reject invalid parameters")
+			t.Errorf("Wanted %v, got %v for %q", ErrInvalidValue, err, query)
+		}
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	// Should advertise a search query
+	opt, err := NewSearchQuery(orderQuery)
+	if err != nil {
+		t.Errorf("Unexpected error from NewSearchQuery: %v", err)
+	}
+	c, err := NewCollection(opt)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	want := query{Href: "/orders/", Rel: "search", Name: "orders", Prompt: "Search Orders", Data: []datum{
+		{Name: "status", Prompt: "Status"},
+		{Name: "status-prefix"},
+		{Name: "min-total"},
+		{Name: "max-total"},
+		{Name: "since"},
+		{Name: "sort", Prompt: "Sort"},
+	}}
+	if !reflect.DeepEqual([]query{want}, c.collection.Queries) {
+		t.Error("Should advertise a search query")
+		t.Errorf("Wanted %+v, got %+v", want, c.collection.Queries)
+	}
+}
@@ -0,0 +1,200 @@
+package producer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ItemIterator provides the items of a collection one at a time to an
+// Encoder. It follows the same pattern as sql.Rows:
+//
+//	for it.Next() {
+//		opt, err := it.Item()
+//		...
+//	}
+//	err := it.Err()
+type ItemIterator interface {
+	// Next prepares the next item, it returns false when there are no more
+	// items or an error occurred.
+	Next() bool
+	// Item returns the Option adding the current item to a collection,
+	// usually created by NewItem or NewItems. A nil Option adds no item.
+	Item() (Option, error)
+	// Err returns the error, if any, that stopped the iteration.
+	Err() error
+}
+
+// ChanIterator is an ItemIterator that reads items from a channel, usually
+// filled by another goroutine. Since Encode can return before the channel is
+// closed, e.g. when the client has gone away, the goroutine sending the items
+// should stop once Done is closed:
+//
+//	it := producer.NewChanIterator(ch)
+//	go func() {
+//		defer close(ch)
+//		for ... {
+//			select {
+//			case ch <- opt:
+//			case <-it.Done():
+//				return
+//			}
+//		}
+//	}()
+//	err := enc.Encode(c, it)
+type ChanIterator struct {
+	ch   <-chan Option
+	cur  Option
+	done chan struct{}
+	once sync.Once
+}
+
+// NewChanIterator returns a ChanIterator that reads items from ch until it is
+// closed.
+func NewChanIterator(ch <-chan Option) *ChanIterator {
+	return &ChanIterator{ch: ch, done: make(chan struct{})}
+}
+
+func (it *ChanIterator) Next() bool {
+	select {
+	case opt, ok := <-it.ch:
+		it.cur = opt
+		return ok
+	case <-it.done:
+		return false
+	}
+}
+
+func (it *ChanIterator) Item() (Option, error) { return it.cur, nil }
+func (it *ChanIterator) Err() error            { return nil }
+
+// Done returns a channel that is closed once the iterator is closed.
+func (it *ChanIterator) Done() <-chan struct{} { return it.done }
+
+// Close stops the iteration and closes the channel returned by Done. It is
+// called by Encode when it returns.
+func (it *ChanIterator) Close() error {
+	it.once.Do(func() { close(it.done) })
+	return nil
+}
+
+// Encoder writes a collection to an io.Writer, streaming its items from an
+// ItemIterator so they never have to be held in memory at once.
+type Encoder struct {
+	w          io.Writer
+	flushEvery int
+}
+
+// NewEncoder returns an Encoder that writes to w. Every flushEvery items the
+// written data is flushed to w, and w itself is flushed if it is an
+// http.Flusher or has a Flush() error method. If flushEvery is less than one
+// data is only flushed once the collection is written.
+func NewEncoder(w io.Writer, flushEvery int) *Encoder {
+	return &Encoder{w: w, flushEvery: flushEvery}
+}
+
+// Encode writes c as a Collection+JSON document, followed by the items from
+// items. The items already in c are written first. Only the items added by the
+// Options from items are written, anything else they add to a collection is
+// ignored. If NewBase was used to create c, the streamed items are rewritten
+// against the base as well.
+//
+// If an error occurs after writing has started the document written is
+// incomplete. If items implements io.Closer it is closed when Encode returns.
+//
+// If NewValidation was used to create c, each item is validated before it is
+// written, and Encode returns the errors of the first invalid item.
+func (e *Encoder) Encode(c Collection, items ItemIterator) error {
+	if closer, ok := items.(io.Closer); ok {
+		defer closer.Close()
+	}
+	envelope := c.collection
+	existing := envelope.Items
+	envelope.Items = nil
+	b, err := Collection{envelope}.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(e.w)
+	// The envelope always ends with the end of the collection object and
+	// the document object, the items are written before them.
+	bw.Write(b[:len(b)-2])
+	bw.WriteString(`,"items":[`)
+	n := 0
+	writeItem := func(itm item) error {
+		if envelope.validate {
+			v := validator{relative: envelope.base != nil && envelope.base.relative}
+			v.item(fmt.Sprintf("items[%d]", n), itm)
+			if err := errors.Join(v.errs...); err != nil {
+				return err
+			}
+		}
+		if n > 0 {
+			bw.WriteByte(',')
+		}
+		ib, err := json.Marshal(itm)
+		if err != nil {
+			return err
+		}
+		bw.Write(ib)
+		n++
+		if e.flushEvery > 0 && n%e.flushEvery == 0 {
+			return e.flush(bw)
+		}
+		return nil
+	}
+
+	for _, itm := range existing {
+		err := writeItem(itm)
+		if err != nil {
+			return err
+		}
+	}
+	for items.Next() {
+		opt, err := items.Item()
+		if err != nil {
+			return err
+		}
+		if opt == nil {
+			continue
+		}
+		scratch := new(collection)
+		err = opt(scratch)
+		if err != nil {
+			return err
+		}
+		if envelope.base != nil {
+			envelope.base.rebase(scratch)
+		}
+		for _, itm := range scratch.Items {
+			err := writeItem(itm)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err := items.Err(); err != nil {
+		return err
+	}
+	bw.WriteString(`]}}`)
+	return e.flush(bw)
+}
+
+func (e *Encoder) flush(bw *bufio.Writer) error {
+	err := bw.Flush()
+	if err != nil {
+		return err
+	}
+	switch f := e.w.(type) {
+	case http.Flusher:
+		f.Flush()
+	case interface{ Flush() error }:
+		return f.Flush()
+	}
+	return nil
+}
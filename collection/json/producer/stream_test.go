@@ -0,0 +1,108 @@
+package producer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type errIterator struct{ n int }
+
+func (it *errIterator) Next() bool { it.n++; return it.n < 3 }
+func (it *errIterator) Item() (Option, error) {
+	return NewItem(url.URL{Path: fmt.Sprintf("/friends/%d", it.n)})
+}
+func (it *errIterator) Err() error { return errors.New("cursor closed") }
+
+func TestEncoder(t *testing.T) {
+	first, err := NewItem(url.URL{Path: "/friends/0"})
+	if err != nil {
+		t.Errorf("Unexpected error from NewItem: %v", err)
+	}
+	c, err := NewCollection(first, NewLink(url.URL{Path: "/friends/"}, "self", "", "", ""))
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	ch := make(chan Option)
+	go func() {
+		for i := 1; i <= 3; i++ {
+			opt, _ := NewItem(url.URL{Path: fmt.Sprintf("/friends/%d", i)}, NewDatum("n", fmt.Sprint(i), ""))
+			ch <- opt
+			// A nil Option adds no item.
+			ch <- nil
+		}
+		close(ch)
+	}()
+
+	// Should be able to stream items into a collection, skipping nil Options
+	w := httptest.NewRecorder()
+	err = NewEncoder(w, 2).Encode(c, NewChanIterator(ch))
+	if err != nil {
+		t.Errorf("Unexpected error from Encode: %v", err)
+	}
+	if !w.Flushed {
+		t.Error("Should flush the writer periodically")
+	}
+	want := `{"collection":{"version":"1.0","links":[{"href":"/friends/","rel":"self"}],"items":[` +
+		`{"href":"/friends/0"},{"href":"/friends/1","data":[{"name":"n","value":"1"}]},` +
+		`{"href":"/friends/2","data":[{"name":"n","value":"2"}]},` +
+		`{"href":"/friends/3","data":[{"name":"n","value":"3"}]}]}}`
+	if w.Body.String() != want {
+		t.Error("Should be able to stream items into a collection")
+		t.Errorf("Wanted %v, got %v", want, w.Body)
+	}
+
+	// Should return the error of the iterator
+	var buf bytes.Buffer
+	err = NewEncoder(&buf, 0).Encode(c, &errIterator{})
+	if err == nil || err.Error() != "cursor closed" {
+		t.Error("Should return the error of the iterator")
+		t.Errorf("Wanted cursor closed, got %v", err)
+	}
+
+	// Should stop the goroutine sending items when Encode returns early
+	ch = make(chan Option)
+	it := NewChanIterator(ch)
+	sender := make(chan struct{})
+	go func() {
+		defer close(sender)
+		for i := 0; ; i++ {
+			opt, _ := NewItem(url.URL{Path: fmt.Sprintf("/friends/%d", i)})
+			select {
+			case ch <- opt:
+			case <-it.Done():
+				return
+			}
+		}
+	}()
+	err = NewEncoder(brokenWriter{}, 1).Encode(c, it)
+	if err == nil {
+		t.Error("Wanted an error from Encode, got nil")
+	}
+	select {
+	case <-sender:
+	case <-time.After(time.Second):
+		t.Error("Should stop the goroutine sending items when Encode returns early")
+	}
+
+	// Should validate streamed items
+	valid, err := NewCollection(NewValidation())
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	buf.Reset()
+	var verr *ValidationError
+	err = NewEncoder(&buf, 0).Encode(valid, &errIterator{})
+	if !errors.As(err, &verr) || verr.Path != "items[0]" {
+		t.Error("Should validate streamed items")
+		t.Errorf("Wanted a *ValidationError for items[0], got %v", err)
+	}
+}
+
+type brokenWriter struct{}
+
+func (brokenWriter) Write(b []byte) (int, error) { return 0, errors.New("broken pipe") }
@@ -0,0 +1,145 @@
+package producer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ValidationError describes a way in which a collection does not conform to
+// the Collection+JSON specification. Path is the location of the problem in
+// the document, e.g. "items[1].links[0]".
+type ValidationError struct {
+	Path    string
+	Problem string
+}
+
+func (e *ValidationError) Error() string {
+	return "producer: " + e.Path + ": " + e.Problem
+}
+
+// NewValidation creates an Option that makes a collection validate itself
+// before it is marshaled into JSON. If the collection is not valid, marshaling
+// fails with the errors returned by Validate. The items streamed into the
+// collection by an Encoder are validated as they are written.
+func NewValidation() Option {
+	return func(i interface{}) error {
+		c, ok := i.(*collection)
+		if !ok {
+			return ErrTypeUnknown
+		}
+		c.validate = true
+		return nil
+	}
+}
+
+// Validate checks that the collection conforms to the Collection+JSON
+// specification. Every problem found is returned as a *ValidationError, joined
+// together with errors.Join. It returns nil if the collection is valid.
+func (c Collection) Validate() error {
+	col := c.collection
+	return col.check(col.base != nil && col.base.relative)
+}
+
+// check checks that c conforms to the Collection+JSON specification. If
+// relative is true, hrefs that are not absolute are allowed.
+func (col *collection) check(relative bool) error {
+	v := validator{relative: relative}
+	if col.Version != "" && col.Version != "1.0" {
+		v.add("collection", fmt.Sprintf("unknown version %q", col.Version))
+	}
+	if col.Href != "" {
+		v.href("collection", col.Href)
+	}
+	v.links("links", col.Links)
+	for i, itm := range col.Items {
+		v.item(fmt.Sprintf("items[%d]", i), itm)
+	}
+	names := make(map[string]struct{})
+	for i, q := range col.Queries {
+		path := fmt.Sprintf("queries[%d]", i)
+		if q.Href == "" {
+			v.add(path, "missing href")
+		} else {
+			v.href(path, q.Href)
+		}
+		if q.Rel == "" {
+			v.add(path, "missing rel")
+		}
+		if q.Name != "" {
+			if _, ok := names[q.Name]; ok {
+				v.add(path, fmt.Sprintf("duplicate name %q", q.Name))
+			}
+			names[q.Name] = struct{}{}
+		}
+		v.data(path, q.Data)
+	}
+	if col.Template != nil {
+		v.data("template", col.Template.Data)
+	}
+	return errors.Join(v.errs...)
+}
+
+type validator struct {
+	errs []error
+	// relative allows hrefs that are not absolute, when hrefs are made
+	// relative by NewBase.
+	relative bool
+}
+
+func (v *validator) add(path, problem string) {
+	v.errs = append(v.errs, &ValidationError{Path: path, Problem: problem})
+}
+
+func (v *validator) href(path, href string) {
+	u, err := url.Parse(href)
+	if err != nil {
+		v.add(path, fmt.Sprintf("invalid href %q", href))
+		return
+	}
+	if !v.relative && (!u.IsAbs() || u.Host == "") {
+		v.add(path, fmt.Sprintf("href %q is not absolute", href))
+	}
+}
+
+func (v *validator) links(path string, links []link) {
+	for i, l := range links {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		if l.Href == "" {
+			v.add(p, "missing href")
+		} else {
+			v.href(p, l.Href)
+		}
+		if l.Rel == "" {
+			v.add(p, "missing rel")
+		}
+		if l.Render != "" && l.Render != "link" && l.Render != "image" {
+			v.add(p, fmt.Sprintf("unknown render %q", l.Render))
+		}
+	}
+}
+
+func (v *validator) item(path string, itm item) {
+	if itm.Href == "" {
+		v.add(path, "missing href")
+	} else {
+		v.href(path, itm.Href)
+	}
+	v.data(path, itm.Data)
+	v.links(path+".links", itm.Links)
+}
+
+func (v *validator) data(path string, data []datum) {
+	names := make(map[string]struct{}, len(data))
+	for i, d := range data {
+		p := fmt.Sprintf("%s.data[%d]", path, i)
+		if d.Name == "" {
+			v.add(p, "missing name")
+			continue
+		}
+		if _, ok := names[d.Name]; ok {
+			v.add(p, fmt.Sprintf("duplicate name %q", d.Name))
+		}
+		names[d.Name] = struct{}{}
+	}
+}
@@ -0,0 +1,78 @@
+package producer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	// Should be able to validate a conforming collection
+	href := url.URL{Scheme: "http", Host: "example.com", Path: "/friends/"}
+	itemOpt, err := NewItem(href, NewLink(href, "self", "", "link", ""))
+	if err != nil {
+		t.Errorf("Unexpected error from NewItem: %v", err)
+	}
+	queryOpt, err := NewQuery(href, "search", "search", "Search", NewDatum("search", "", ""))
+	if err != nil {
+		t.Errorf("Unexpected error from NewQuery: %v", err)
+	}
+	c, err := NewCollection(NewLink(href, "feed", "", "", ""), itemOpt, queryOpt)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	err = c.Validate()
+	if err != nil {
+		t.Error("Should be able to validate a conforming collection")
+		t.Errorf("Wanted nil, got %v", err)
+	}
+
+	// Should return every problem with a collection
+	relative := url.URL{Path: "/friends/jdoe"}
+	itemOpt, err = NewItem(url.URL{}, NewDatum("", "", ""))
+	if err != nil {
+		t.Errorf("Unexpected error from NewItem: %v", err)
+	}
+	c, err = NewCollection(
+		NewLink(relative, "", "", "button", ""),
+		itemOpt,
+		queryOpt,
+		queryOpt,
+	)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	err = c.Validate()
+	want := []string{
+		`producer: links[0]: href "/friends/jdoe" is not absolute`,
+		`producer: links[0]: missing rel`,
+		`producer: links[0]: unknown render "button"`,
+		`producer: items[0]: missing href`,
+		`producer: items[0].data[0]: missing name`,
+		`producer: queries[1]: duplicate name "search"`,
+	}
+	errs := err.(interface{ Unwrap() []error }).Unwrap()
+	if len(errs) != len(want) {
+		t.Error("Should return every problem with a collection")
+		t.Errorf("Wanted %d problems, got %v", len(want), err)
+	}
+	for i := 0; i < len(errs) && i < len(want); i++ {
+		var ve *ValidationError
+		if !errors.As(errs[i], &ve) || ve.Error() != want[i] {
+			t.Errorf("Wanted %v, got %v", want[i], errs[i])
+		}
+	}
+
+	// Should validate before marshaling when configured to
+	c, err = NewCollection(NewValidation(), NewLink(relative, "self", "", "", ""))
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	_, err = json.Marshal(c)
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Error("Should validate before marshaling when configured to")
+		t.Errorf("Wanted a *ValidationError, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package producer
+
+// With returns a new Collection with opts applied to a copy of c, e.g. to
+// add links depending on the user making the request:
+//
+//	c, err = c.With(NewLink(edit, "edit", "", "", "Edit"))
+//
+// c itself is left unchanged, so it can be extended by several goroutines at
+// once. If NewBase was used to create c, the hrefs of the new Collection,
+// including those added by opts, are rewritten against the base. An entity
+// tag set by NewETag is cleared when opts are given, since they change the
+// content it identifies, unless opts set it again.
+func (c Collection) With(opts ...Option) (Collection, error) {
+	src := c.collection
+	if src.unbased != nil {
+		src = *src.unbased
+	}
+	cp := src.clone()
+	if len(opts) > 0 {
+		cp.etag = ""
+	}
+	for _, opt := range opts {
+		err := opt(cp)
+		if err != nil {
+			return Collection{}, err
+		}
+	}
+	return finish(cp), nil
+}
+
+// clone returns a deep copy of c, so that options applied to the copy cannot
+// change c. The values of data are not copied, options do not change them.
+func (c collection) clone() *collection {
+	cp := c
+	cp.unbased = nil
+	cp.Links = cloneLinks(c.Links)
+	if c.Items != nil {
+		cp.Items = make([]item, len(c.Items))
+		for i, itm := range c.Items {
+			itm.Data = cloneData(itm.Data)
+			itm.Links = cloneLinks(itm.Links)
+			cp.Items[i] = itm
+		}
+	}
+	if c.Queries != nil {
+		cp.Queries = make([]query, len(c.Queries))
+		for i, q := range c.Queries {
+			q.Data = cloneData(q.Data)
+			cp.Queries[i] = q
+		}
+	}
+	if c.Template != nil {
+		tmpl := *c.Template
+		tmpl.Data = cloneData(tmpl.Data)
+		cp.Template = &tmpl
+	}
+	if c.Error != nil {
+		cjErr := *c.Error
+		cp.Error = &cjErr
+	}
+	if c.Errors != nil {
+		cp.Errors = append(make([]cjFieldError, 0, len(c.Errors)), c.Errors...)
+	}
+	return &cp
+}
+
+func cloneLinks(links []link) []link {
+	if links == nil {
+		return nil
+	}
+	return append(make([]link, 0, len(links)), links...)
+}
+
+func cloneData(data []datum) []datum {
+	if data == nil {
+		return nil
+	}
+	return append(make([]datum, 0, len(data)), data...)
+}
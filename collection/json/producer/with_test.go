@@ -0,0 +1,129 @@
+package producer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestWith(t *testing.T) {
+	itm, err := NewItem(url.URL{Path: "/friends/jdoe"}, NewDatum("full-name", "J. Doe", "Full Name"))
+	if err != nil {
+		t.Errorf("Unexpected error from NewItem: %v", err)
+	}
+	tmpl, err := NewTemplate(NewDatum("full-name", "", "Full Name"))
+	if err != nil {
+		t.Errorf("Unexpected error from NewTemplate: %v", err)
+	}
+	c, err := NewCollection(
+		NewLink(url.URL{Path: "/friends/"}, "self", "", "", ""),
+		itm,
+		tmpl,
+		NewBase(url.URL{Scheme: "https", Host: "api.example.com", Path: "/v1"}, false),
+	)
+	if err != nil {
+		t.Errorf("Unexpected error from NewCollection: %v", err)
+	}
+	before, err := c.MarshalJSON()
+	if err != nil {
+		t.Errorf("Unexpected error from MarshalJSON: %v", err)
+	}
+
+	// Should be able to extend a collection concurrently
+	var wg sync.WaitGroup
+	results := make([]Collection, 8)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			href := url.URL{Path: fmt.Sprintf("/friends/%d/edit", i)}
+			results[i], errs[i] = c.With(NewLink(href, "edit", "", "", ""), NewError("", "", ""))
+		}(i)
+	}
+	wg.Wait()
+	for i, res := range results {
+		if errs[i] != nil {
+			t.Errorf("Unexpected error from With: %v", errs[i])
+			continue
+		}
+		want := []link{
+			{Href: "https://api.example.com/v1/friends/", Rel: "self"},
+			{Href: fmt.Sprintf("https://api.example.com/v1/friends/%d/edit", i), Rel: "edit"},
+		}
+		if !reflect.DeepEqual(want, res.collection.Links) {
+			t.Error("Should be able to extend a collection concurrently")
+			t.Errorf("Wanted %+v, got %+v", want, res.collection.Links)
+		}
+		if res.collection.Items[0].Href != "https://api.example.com/v1/friends/jdoe" {
+			t.Error("Should rewrite the hrefs of an extended collection once")
+			t.Errorf("Wanted %v, got %v", "https://api.example.com/v1/friends/jdoe", res.collection.Items[0].Href)
+		}
+	}
+
+	// Should leave the original collection unchanged
+	after, err := c.MarshalJSON()
+	if err != nil {
+		t.Errorf("Unexpected error from MarshalJSON: %v", err)
+	}
+	if string(before) != string(after) {
+		t.Error("Should leave the original collection unchanged")
+		t.Errorf("Wanted %s, got %s", before, after)
+	}
+
+	// Should not share the template of the original collection
+	ext, err := c.With()
+	if err != nil {
+		t.Errorf("Unexpected error from With: %v", err)
+	}
+	ext.collection.Template.Data[0].Value = "changed"
+	if c.collection.Template.Data[0].Value != nil {
+		t.Error("Should not share the template of the original collection")
+	}
+
+	// Should keep the properties added by extensions to the template
+	var loaded Collection
+	err = json.Unmarshal([]byte(`{"collection":{"template":{"data":[],"method":"put"}}}`), &loaded)
+	if err != nil {
+		t.Errorf("Unexpected error from json.Unmarshal: %v", err)
+	}
+	extended, err := loaded.With(NewHref(url.URL{Path: "/friends/"}))
+	if err != nil {
+		t.Errorf("Unexpected error from With: %v", err)
+	}
+	b, _ := json.Marshal(extended)
+	want := `{"collection":{"version":"1.0","href":"/friends/","template":{"data":[],"method":"put"}}}`
+	if string(b) != want {
+		t.Error("Should keep the properties added by extensions to the template")
+		t.Errorf("Wanted %v, got %s", want, b)
+	}
+
+	// Should clear the entity tag set by NewETag when changing the collection
+	versioned, err := c.With(NewETag(`"7"`))
+	if err != nil {
+		t.Errorf("Unexpected error from With: %v", err)
+	}
+	if etag, _ := versioned.ETag(); etag != `"7"` {
+		t.Errorf("Wanted %v, got %v", `"7"`, etag)
+	}
+	changed, err := versioned.With(NewLink(url.URL{Path: "/friends/rss"}, "feed", "", "", ""))
+	if err != nil {
+		t.Errorf("Unexpected error from With: %v", err)
+	}
+	if etag, _ := changed.ETag(); etag == `"7"` {
+		t.Error("Should clear the entity tag set by NewETag when changing the collection")
+	}
+	if same, _ := versioned.With(); same.collection.etag != `"7"` {
+		t.Errorf("Wanted %v, got %v", `"7"`, same.collection.etag)
+	}
+
+	// Should return the error of an option
+	_, err = c.With(NewDatum("foo", "bar", "baz"))
+	if err != ErrTypeUnknown {
+		t.Error("Should return the error of an option")
+		t.Errorf("Wanted %v, got %v", ErrTypeUnknown, err)
+	}
+}
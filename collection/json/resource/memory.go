@@ -0,0 +1,90 @@
+package resource
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// Memory is a Store that holds values in memory, listing them in the order
+// they were created. IDs are assigned sequentially starting at "1", as are the
+// versions of each value. A Memory is safe for concurrent use, Update and
+// Delete check the version of a value under the same lock they change it.
+type Memory[T any] struct {
+	mu      sync.RWMutex
+	next    int
+	ids     []string
+	entries map[string]Entry[T]
+}
+
+// NewMemory returns an empty Memory store.
+func NewMemory[T any]() *Memory[T] {
+	return &Memory[T]{entries: make(map[string]Entry[T])}
+}
+
+func (m *Memory[T]) List(ctx context.Context) ([]Entry[T], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entries := make([]Entry[T], len(m.ids))
+	for i, id := range m.ids {
+		entries[i] = m.entries[id]
+	}
+	return entries, nil
+}
+
+func (m *Memory[T]) Get(ctx context.Context, id string) (Entry[T], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.entries[id]
+	if !ok {
+		return Entry[T]{}, ErrNotFound
+	}
+	return e, nil
+}
+
+func (m *Memory[T]) Create(ctx context.Context, v T) (Entry[T], error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.next++
+	id := strconv.Itoa(m.next)
+	e := Entry[T]{ID: id, Value: v, Version: "1"}
+	m.ids = append(m.ids, id)
+	m.entries[id] = e
+	return e, nil
+}
+
+func (m *Memory[T]) Update(ctx context.Context, id string, v T, ifVersion string) (Entry[T], error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[id]
+	if !ok {
+		return Entry[T]{}, ErrNotFound
+	}
+	if ifVersion != "" && ifVersion != e.Version {
+		return Entry[T]{}, ErrConflict
+	}
+	version, _ := strconv.Atoi(e.Version)
+	e.Value, e.Version = v, strconv.Itoa(version+1)
+	m.entries[id] = e
+	return e, nil
+}
+
+func (m *Memory[T]) Delete(ctx context.Context, id string, ifVersion string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if ifVersion != "" && ifVersion != e.Version {
+		return ErrConflict
+	}
+	delete(m.entries, id)
+	for i, other := range m.ids {
+		if other == id {
+			m.ids = append(m.ids[:i:i], m.ids[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
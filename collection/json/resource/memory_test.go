@@ -0,0 +1,75 @@
+package resource
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory[string]()
+
+	// Should list values in the order they were created with their versions
+	for _, v := range []string{"a", "b", "c"} {
+		_, err := m.Create(ctx, v)
+		if err != nil {
+			t.Errorf("Unexpected error from Create: %v", err)
+		}
+	}
+	err := m.Delete(ctx, "2", "1")
+	if err != nil {
+		t.Errorf("Unexpected error from Delete: %v", err)
+	}
+	e, err := m.Create(ctx, "d")
+	if err != nil || e.ID != "4" {
+		t.Errorf("Wanted %v, got %v %v", "4", e.ID, err)
+	}
+	_, err = m.Update(ctx, "3", "C", "")
+	if err != nil {
+		t.Errorf("Unexpected error from Update: %v", err)
+	}
+	got, err := m.List(ctx)
+	if err != nil {
+		t.Errorf("Unexpected error from List: %v", err)
+	}
+	want := []Entry[string]{{"1", "a", "1"}, {"3", "C", "2"}, {"4", "d", "1"}}
+	if !reflect.DeepEqual(want, got) {
+		t.Error("Should list values in the order they were created with their versions")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	// Should return ErrNotFound for missing values
+	_, err = m.Get(ctx, "2")
+	if err != ErrNotFound {
+		t.Error("Should return ErrNotFound for missing values")
+		t.Errorf("Wanted %v, got %v", ErrNotFound, err)
+	}
+	_, err = m.Update(ctx, "2", "b", "")
+	if err != ErrNotFound {
+		t.Errorf("Wanted %v, got %v", ErrNotFound, err)
+	}
+	err = m.Delete(ctx, "2", "")
+	if err != ErrNotFound {
+		t.Errorf("Wanted %v, got %v", ErrNotFound, err)
+	}
+
+	// Should only change values at the version they are conditioned on
+	_, err = m.Update(ctx, "3", "c", "1")
+	if err != ErrConflict {
+		t.Error("Should only change values at the version they are conditioned on")
+		t.Errorf("Wanted %v, got %v", ErrConflict, err)
+	}
+	err = m.Delete(ctx, "3", "1")
+	if err != ErrConflict {
+		t.Errorf("Wanted %v, got %v", ErrConflict, err)
+	}
+	e, err = m.Update(ctx, "3", "c", "2")
+	if err != nil || e.Version != "3" {
+		t.Errorf("Wanted %v, got %v %v", "3", e.Version, err)
+	}
+	err = m.Delete(ctx, "3", "3")
+	if err != nil {
+		t.Errorf("Unexpected error from Delete: %v", err)
+	}
+}
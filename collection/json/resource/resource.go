@@ -0,0 +1,402 @@
+/*
+Package resource serves a Collection+JSON resource backed by a Store. Given a
+struct type with cj struct tags, a Resource serves the listing of the stored
+values, each value as an item, and creates, updates, and deletes values from
+the write representation of its template:
+
+	type Friend struct {
+		FullName string `cj:"datum,Full Name,full-name"`
+		Email    string `cj:"datum,Email,email"`
+	}
+
+	res, err := resource.New[Friend]("/friends/", resource.NewMemory[Friend]())
+	...
+	mux := http.NewServeMux()
+	res.Mount(mux)
+*/
+package resource
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/skriptble/hyper/collection/json/producer"
+)
+
+// ErrNotFound is returned by a Store when there is no value with an ID. It is
+// served as a 404.
+var ErrNotFound = errors.New("resource: not found")
+
+// ErrConflict is returned by a Store when a value is not at the version an
+// update or delete is conditioned on. It is served as a 412.
+var ErrConflict = errors.New("resource: version conflict")
+
+// ErrNoVersion is returned when a Store returns an Entry without a version.
+var ErrNoVersion = errors.New("resource: entry has no version")
+
+// ErrInvalidPath is returned by New when the path of a Resource does not start
+// and end with a slash.
+var ErrInvalidPath = errors.New("resource: path must start and end with a slash")
+
+// Entry is a value held by a Store along with its ID. Version identifies the
+// current version of the value and changes whenever the value is updated. It
+// is required, a value served without one fails with ErrNoVersion. It is sent
+// as an entity tag, so it must not contain double quotes.
+type Entry[T any] struct {
+	ID      string
+	Value   T
+	Version string
+}
+
+// Store holds the values served by a Resource. Get, Update, and Delete return
+// ErrNotFound, or an error wrapping it, when there is no value with the ID.
+//
+// When ifVersion is not empty, Update and Delete only change the value if
+// ifVersion is its current version and otherwise return ErrConflict, or an
+// error wrapping it. Checking the version and changing the value must be done
+// atomically, so that a value changed by another writer in between is not
+// overwritten. An empty ifVersion changes the value whatever its version.
+type Store[T any] interface {
+	List(ctx context.Context) ([]Entry[T], error)
+	Get(ctx context.Context, id string) (Entry[T], error)
+	Create(ctx context.Context, v T) (Entry[T], error)
+	Update(ctx context.Context, id string, v T, ifVersion string) (Entry[T], error)
+	Delete(ctx context.Context, id string, ifVersion string) error
+}
+
+// Option configures a Resource created by New. If the Option is passed into a
+// New function for a type it does not support it will return
+// producer.ErrTypeUnknown.
+type Option func(interface{}) error
+
+// options holds the configuration of a Resource.
+type options struct {
+	queries  []producer.SearchQuery
+	registry *producer.Registry
+}
+
+// NewQueries creates an Option that adds queries to a Resource. Each of
+// queries is advertised in the listing. A request for the listing is answered
+// by the first query whose href has the path of the request, see
+// producer.NewSearch, a query without an href has the path of the Resource.
+// Without a matching query every value is listed.
+func NewQueries(queries ...producer.SearchQuery) Option {
+	return func(i interface{}) error {
+		o, ok := i.(*options)
+		if !ok {
+			return producer.ErrTypeUnknown
+		}
+		o.queries = append(o.queries, queries...)
+		return nil
+	}
+}
+
+// NewRegistry creates an Option that sets the Registry representing the
+// errors of a Resource. Without it producer.DefaultRegistry is used.
+func NewRegistry(reg *producer.Registry) Option {
+	return func(i interface{}) error {
+		o, ok := i.(*options)
+		if !ok {
+			return producer.ErrTypeUnknown
+		}
+		o.registry = reg
+		return nil
+	}
+}
+
+// Resource serves the values of a Store as a Collection+JSON resource.
+type Resource[T any] struct {
+	path     string
+	store    Store[T]
+	registry *producer.Registry
+	queries  []producer.SearchQuery
+	// advertised holds the Options adding the queries to the listing.
+	advertised []producer.Option
+	template   producer.Option
+	decoder    producer.Decoder
+}
+
+// MaxBytes is the maximum size of a submitted template.
+const MaxBytes = 1 << 20
+
+// New returns a Resource serving the values of store under path, e.g.
+// "/friends/", configured by the given options. The template of the Resource
+// is derived from T, see producer.NewTemplateFrom.
+func New[T any](path string, store Store[T], opts ...Option) (*Resource[T], error) {
+	if !strings.HasPrefix(path, "/") || !strings.HasSuffix(path, "/") {
+		return nil, ErrInvalidPath
+	}
+	o := options{registry: producer.DefaultRegistry}
+	for _, opt := range opts {
+		err := opt(&o)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var zero T
+	tmpl, err := producer.NewTemplateFrom(zero)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := producer.NewDecoder(tmpl, MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	res := &Resource[T]{path: path, store: store, registry: o.registry, template: tmpl, decoder: dec}
+	for _, q := range o.queries {
+		if q.Href == (url.URL{}) {
+			q.Href = url.URL{Path: path}
+		}
+		opt, err := producer.NewSearchQuery(q)
+		if err != nil {
+			return nil, err
+		}
+		res.queries = append(res.queries, q)
+		res.advertised = append(res.advertised, opt)
+	}
+	return res, nil
+}
+
+// Mount registers the Resource on mux under its path, routing requests by
+// their method and path:
+//
+//	GET    /friends/     the listing
+//	POST   /friends/     create a value, 201 with its Location
+//	GET    /friends/{id} a value
+//	PUT    /friends/{id} update a value
+//	DELETE /friends/{id} delete a value, 204
+//
+// The response for a value carries its version as its entity tag. A PUT or
+// DELETE with an If-Match header that does not match the current version of
+// the value is rejected with a 412. The version is checked by the Store as it
+// changes the value, see Store.
+//
+// Other methods are answered with a 405 and paths below a value with a 404.
+func (res *Resource[T]) Mount(mux *http.ServeMux) {
+	list := res.registry.Handler(res.list)
+	get := res.registry.Handler(res.get)
+	mux.HandleFunc(res.path, func(w http.ResponseWriter, r *http.Request) {
+		id := res.id(r)
+		switch {
+		case id == "" && r.Method == http.MethodGet:
+			list.ServeHTTP(w, r)
+		case id == "" && r.Method == http.MethodPost:
+			res.create(w, r)
+		case id == "":
+			notAllowed(w, "GET, POST")
+		case strings.Contains(id, "/"):
+			http.NotFound(w, r)
+		case r.Method == http.MethodGet:
+			get.ServeHTTP(w, r)
+		case r.Method == http.MethodPut:
+			res.update(w, r)
+		case r.Method == http.MethodDelete:
+			res.delete(w, r)
+		default:
+			notAllowed(w, "GET, PUT, DELETE")
+		}
+	})
+}
+
+// id returns the ID of the value r is for, it is empty for the listing.
+func (res *Resource[T]) id(r *http.Request) string {
+	return strings.TrimPrefix(r.URL.Path, res.path)
+}
+
+// notAllowed answers a request whose method is not one of allow with a 405.
+func notAllowed(w http.ResponseWriter, allow string) {
+	w.Header().Set("Allow", allow)
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+func (res *Resource[T]) list(r *http.Request) (producer.Collection, error) {
+	entries, err := res.store.List(r.Context())
+	if err != nil {
+		return producer.Collection{}, err
+	}
+	items := make([]producer.Option, 0, len(entries))
+	for _, e := range entries {
+		itm, err := producer.NewItemFrom(res.href(e.ID), e.Value)
+		if err != nil {
+			return producer.Collection{}, err
+		}
+		items = append(items, itm)
+	}
+
+	opts := append([]producer.Option{producer.NewHref(url.URL{Path: res.path}), res.template}, res.advertised...)
+	for _, q := range res.queries {
+		if q.Href.Path != r.URL.Path {
+			continue
+		}
+		search, err := producer.NewSearch(q, *r.URL, items)
+		if err != nil {
+			return producer.Collection{}, err
+		}
+		return producer.NewCollection(append(opts, search)...)
+	}
+	return producer.NewCollection(append(opts, items...)...)
+}
+
+func (res *Resource[T]) get(r *http.Request) (producer.Collection, error) {
+	e, err := res.store.Get(r.Context(), res.id(r))
+	if err != nil {
+		return producer.Collection{}, storeError(err)
+	}
+	return res.item(e)
+}
+
+func (res *Resource[T]) create(w http.ResponseWriter, r *http.Request) {
+	var v T
+	err := res.decoder.Decode(r.Body, &v)
+	if err != nil {
+		res.error(w, r, err)
+		return
+	}
+	e, err := res.store.Create(r.Context(), v)
+	if err != nil {
+		res.error(w, r, err)
+		return
+	}
+	c, err := res.item(e)
+	if err != nil {
+		res.error(w, r, err)
+		return
+	}
+	href := res.href(e.ID)
+	w.Header().Set("Location", href.String())
+	res.write(w, r, http.StatusCreated, c)
+}
+
+func (res *Resource[T]) update(w http.ResponseWriter, r *http.Request) {
+	var v T
+	err := res.decoder.Decode(r.Body, &v)
+	if err != nil {
+		res.error(w, r, err)
+		return
+	}
+	version, err := res.ifVersion(r)
+	if err != nil {
+		res.error(w, r, err)
+		return
+	}
+	e, err := res.store.Update(r.Context(), res.id(r), v, version)
+	if err != nil {
+		res.error(w, r, storeError(err))
+		return
+	}
+	c, err := res.item(e)
+	if err != nil {
+		res.error(w, r, err)
+		return
+	}
+	res.write(w, r, http.StatusOK, c)
+}
+
+func (res *Resource[T]) delete(w http.ResponseWriter, r *http.Request) {
+	version, err := res.ifVersion(r)
+	if err != nil {
+		res.error(w, r, err)
+		return
+	}
+	err = res.store.Delete(r.Context(), res.id(r), version)
+	if err != nil {
+		res.error(w, r, storeError(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ifVersion returns the version the change r makes is conditioned on by its
+// If-Match header. It is empty when r has no If-Match header or the header is
+// "*". Weak entity tags never match, as with producer.CheckIfMatch. Only a
+// header listing several entity tags needs the current version of the value,
+// which the change is then conditioned on.
+func (res *Resource[T]) ifVersion(r *http.Request) (string, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return "", nil
+	}
+	var versions []string
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return "", nil
+		}
+		if len(tag) > 2 && tag[0] == '"' && tag[len(tag)-1] == '"' {
+			versions = append(versions, tag[1:len(tag)-1])
+		}
+	}
+	switch len(versions) {
+	case 0:
+		return "", producer.ErrPreconditionFailed
+	case 1:
+		return versions[0], nil
+	}
+	e, err := res.store.Get(r.Context(), res.id(r))
+	if err != nil {
+		return "", storeError(err)
+	}
+	for _, v := range versions {
+		if v == e.Version {
+			return v, nil
+		}
+	}
+	return "", producer.ErrPreconditionFailed
+}
+
+// item returns the collection holding the value of e.
+func (res *Resource[T]) item(e Entry[T]) (producer.Collection, error) {
+	href := res.href(e.ID)
+	itm, err := producer.NewItemFrom(href, e.Value)
+	if err != nil {
+		return producer.Collection{}, err
+	}
+	if e.Version == "" {
+		return producer.Collection{}, ErrNoVersion
+	}
+	return producer.NewCollection(producer.NewHref(href), itm, res.template, producer.NewETag(`"`+e.Version+`"`))
+}
+
+func (res *Resource[T]) href(id string) url.URL {
+	return url.URL{Path: res.path + id}
+}
+
+func (res *Resource[T]) error(w http.ResponseWriter, r *http.Request, err error) {
+	c, status := res.registry.Collection(r, err)
+	res.write(w, r, status, c)
+}
+
+func (res *Resource[T]) write(w http.ResponseWriter, r *http.Request, status int, c producer.Collection) {
+	hw := &headerWriter{ResponseWriter: w}
+	err := producer.Write(hw, r, status, c)
+	// Once the status has been sent there is nothing to add.
+	if err != nil && !hw.wroteHeader {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// headerWriter records whether the status of a response has been sent.
+type headerWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *headerWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// storeError converts ErrNotFound into a 404 StatusError and ErrConflict into
+// producer.ErrPreconditionFailed.
+func storeError(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return &producer.StatusError{Status: http.StatusNotFound}
+	case errors.Is(err, ErrConflict):
+		return producer.ErrPreconditionFailed
+	}
+	return err
+}
@@ -0,0 +1,334 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/skriptble/hyper/collection/json/producer"
+)
+
+type friend struct {
+	FullName string `cj:"datum,Full Name,full-name"`
+	Email    string `cj:"datum,Email,email"`
+}
+
+func serve(mux *http.ServeMux, method, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w
+}
+
+func TestResource(t *testing.T) {
+	search := producer.SearchQuery{
+		Rel:     "search",
+		Name:    "friends",
+		Prompt:  "Search",
+		Filters: []producer.Filter{{Param: "email", Op: producer.Equal, Prompt: "Email"}},
+	}
+	res, err := New[friend]("/friends/", NewMemory[friend](), NewQueries(search))
+	if err != nil {
+		t.Fatalf("Unexpected error from New: %v", err)
+	}
+	mux := http.NewServeMux()
+	res.Mount(mux)
+
+	// Should create a value from the write representation
+	w := serve(mux, http.MethodPost, "/friends/",
+		`{"template":{"data":[{"name":"full-name","value":"J. Doe"},{"name":"email","value":"jdoe@example.org"}]}}`)
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/friends/1" {
+		t.Error("Should create a value from the write representation")
+		t.Errorf("Wanted %v %v, got %v %v %s", http.StatusCreated, "/friends/1", w.Code, w.Header().Get("Location"), w.Body)
+	}
+	serve(mux, http.MethodPost, "/friends/",
+		`{"template":{"data":[{"name":"full-name","value":"M. Smith"},{"name":"email","value":"msmith@example.org"}]}}`)
+
+	// Should serve the listing with items, template, and queries
+	tmpl := `"template":{"data":[{"name":"full-name","prompt":"Full Name"},{"name":"email","prompt":"Email"}]}`
+	want := `{"collection":{"version":"1.0","href":"/friends/","items":[` +
+		`{"href":"/friends/1","data":[{"name":"full-name","value":"J. Doe","prompt":"Full Name"},{"name":"email","value":"jdoe@example.org","prompt":"Email"}]},` +
+		`{"href":"/friends/2","data":[{"name":"full-name","value":"M. Smith","prompt":"Full Name"},{"name":"email","value":"msmith@example.org","prompt":"Email"}]}],` +
+		`"queries":[{"href":"/friends/","rel":"search","name":"friends","prompt":"Search","data":[{"name":"email","prompt":"Email"}]}],` +
+		tmpl + `}}`
+	w = serve(mux, http.MethodGet, "/friends/", "")
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Error("Should serve the listing with items, template, and queries")
+		t.Errorf("Wanted %v, got %v %s", want, w.Code, w.Body)
+	}
+
+	// Should answer queries of the listing
+	w = serve(mux, http.MethodGet, "/friends/?email="+url.QueryEscape("msmith@example.org"), "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"items":[{"href":"/friends/2"`) ||
+		strings.Contains(w.Body.String(), `"/friends/1"`) {
+		t.Error("Should answer queries of the listing")
+		t.Errorf("Got %v %s", w.Code, w.Body)
+	}
+
+	// Should update a value
+	w = serve(mux, http.MethodPut, "/friends/1",
+		`{"template":{"data":[{"name":"full-name","value":"Jane Doe"},{"name":"email","value":"jdoe@example.org"}]}}`)
+	want = `{"collection":{"version":"1.0","href":"/friends/1","items":[` +
+		`{"href":"/friends/1","data":[{"name":"full-name","value":"Jane Doe","prompt":"Full Name"},{"name":"email","value":"jdoe@example.org","prompt":"Email"}]}],` +
+		tmpl + `}}`
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Error("Should update a value")
+		t.Errorf("Wanted %v, got %v %s", want, w.Code, w.Body)
+	}
+	w = serve(mux, http.MethodGet, "/friends/1", "")
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Error("Should serve a value")
+		t.Errorf("Wanted %v, got %v %s", want, w.Code, w.Body)
+	}
+
+	// Should reject an invalid write representation
+	w = serve(mux, http.MethodPut, "/friends/1", `{"template":{"data":[{"name":"full-name","value":"Jane Doe"}]}}`)
+	if w.Code != http.StatusBadRequest {
+		t.Error("Should reject an invalid write representation")
+		t.Errorf("Wanted %v, got %v %s", http.StatusBadRequest, w.Code, w.Body)
+	}
+
+	// Should reject a body that is not a write representation
+	for _, body := range []string{`{not json`, `{"template":{"data":"x"}}`} {
+		w = serve(mux, http.MethodPost, "/friends/", body)
+		if w.Code != http.StatusBadRequest {
+			t.Error("Should reject a body that is not a write representation")
+			t.Errorf("Wanted %v, got %v %s for %s", http.StatusBadRequest, w.Code, w.Body, body)
+		}
+	}
+
+	// Should delete a value
+	w = serve(mux, http.MethodDelete, "/friends/1", "")
+	if w.Code != http.StatusNoContent {
+		t.Error("Should delete a value")
+		t.Errorf("Wanted %v, got %v %s", http.StatusNoContent, w.Code, w.Body)
+	}
+
+	// Should serve a 404 for missing values
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		w = serve(mux, method, "/friends/1",
+			`{"template":{"data":[{"name":"full-name","value":"J. Doe"},{"name":"email","value":"jdoe@example.org"}]}}`)
+		if w.Code != http.StatusNotFound {
+			t.Error("Should serve a 404 for missing values")
+			t.Errorf("Wanted %v, got %v %s for %v", http.StatusNotFound, w.Code, w.Body, method)
+		}
+	}
+
+	// Should route requests by their method and path
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodDelete, "/friends/", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/friends/1", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/friends/1/photos", http.StatusNotFound},
+		{http.MethodGet, "/friends", http.StatusMovedPermanently},
+	}
+	for _, test := range tests {
+		w = serve(mux, test.method, test.path, "")
+		if w.Code != test.want {
+			t.Error("Should route requests by their method and path")
+			t.Errorf("Wanted %v, got %v for %v %v", test.want, w.Code, test.method, test.path)
+		}
+	}
+
+	// Should represent errors using the Registry of the resource
+	reg := producer.NewRegistry()
+	err = reg.Register(errFull, producer.ErrorMapping{Status: http.StatusInsufficientStorage, Message: "There is no room for more friends."})
+	if err != nil {
+		t.Errorf("Unexpected error from Register: %v", err)
+	}
+	res, err = New[friend]("/friends/", full{NewMemory[friend]()}, NewRegistry(reg))
+	if err != nil {
+		t.Fatalf("Unexpected error from New: %v", err)
+	}
+	mux = http.NewServeMux()
+	res.Mount(mux)
+	w = serve(mux, http.MethodPost, "/friends/",
+		`{"template":{"data":[{"name":"full-name","value":"J. Doe"},{"name":"email","value":"jdoe@example.org"}]}}`)
+	if w.Code != http.StatusInsufficientStorage || !strings.Contains(w.Body.String(), "There is no room for more friends.") {
+		t.Error("Should represent errors using the Registry of the resource")
+		t.Errorf("Wanted %v, got %v %s", http.StatusInsufficientStorage, w.Code, w.Body)
+	}
+
+	// Should not be able to serve a resource under an invalid path
+	_, err = New[friend]("friends", NewMemory[friend]())
+	if err != ErrInvalidPath {
+		t.Error("Should not be able to serve a resource under an invalid path")
+		t.Errorf("Wanted %v, got %v", ErrInvalidPath, err)
+	}
+
+	// Should not be able to pass an unknown option to New
+	_, err = New[friend]("/friends/", NewMemory[friend](), func(interface{}) error { return producer.ErrTypeUnknown })
+	if err != producer.ErrTypeUnknown {
+		t.Error("Should not be able to pass an unknown option to New")
+		t.Errorf("Wanted %v, got %v", producer.ErrTypeUnknown, err)
+	}
+}
+
+func TestResourceVersions(t *testing.T) {
+	res, err := New[friend]("/friends/", NewMemory[friend]())
+	if err != nil {
+		t.Fatalf("Unexpected error from New: %v", err)
+	}
+	mux := http.NewServeMux()
+	res.Mount(mux)
+	jdoe := `{"template":{"data":[{"name":"full-name","value":"J. Doe"},{"name":"email","value":"jdoe@example.org"}]}}`
+	serve(mux, http.MethodPost, "/friends/", jdoe)
+
+	// Should send the version of a value as its entity tag
+	w := serve(mux, http.MethodGet, "/friends/1", "")
+	if w.Header().Get("ETag") != `"1"` {
+		t.Error("Should send the version of a value as its entity tag")
+		t.Errorf("Wanted %v, got %v", `"1"`, w.Header().Get("ETag"))
+	}
+	r := httptest.NewRequest(http.MethodGet, "/friends/1", nil)
+	r.Header.Set("If-None-Match", `"1"`)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusNotModified {
+		t.Error("Should answer a matching If-None-Match with a 304")
+		t.Errorf("Wanted %v, got %v", http.StatusNotModified, w.Code)
+	}
+
+	// Should update a value whose version matches If-Match
+	r = httptest.NewRequest(http.MethodPut, "/friends/1", strings.NewReader(jdoe))
+	r.Header.Set("If-Match", `"1"`)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Error("Should update a value whose version matches If-Match")
+		t.Errorf("Wanted %v %v, got %v %v %s", http.StatusOK, `"2"`, w.Code, w.Header().Get("ETag"), w.Body)
+	}
+
+	// Should reject stale updates and deletes with a 412
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		r = httptest.NewRequest(method, "/friends/1", strings.NewReader(jdoe))
+		r.Header.Set("If-Match", `"1"`)
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != http.StatusPreconditionFailed || !strings.Contains(w.Body.String(), `"code":"412"`) {
+			t.Error("Should reject stale updates and deletes with a 412")
+			t.Errorf("Wanted %v, got %v %s for %v", http.StatusPreconditionFailed, w.Code, w.Body, method)
+		}
+	}
+
+	// Should reject an update when another writer has changed the value
+	res, err = New[friend]("/friends/", racing{NewMemory[friend]()})
+	if err != nil {
+		t.Fatalf("Unexpected error from New: %v", err)
+	}
+	mux = http.NewServeMux()
+	res.Mount(mux)
+	serve(mux, http.MethodPost, "/friends/", jdoe)
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		r = httptest.NewRequest(method, "/friends/1", strings.NewReader(jdoe))
+		r.Header.Set("If-Match", serve(mux, http.MethodGet, "/friends/1", "").Header().Get("ETag"))
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != http.StatusPreconditionFailed {
+			t.Error("Should reject an update when another writer has changed the value")
+			t.Errorf("Wanted %v, got %v %s for %v", http.StatusPreconditionFailed, w.Code, w.Body, method)
+		}
+	}
+
+	// Should let only one of concurrent updates of the same version succeed
+	res, err = New[friend]("/friends/", NewMemory[friend]())
+	if err != nil {
+		t.Fatalf("Unexpected error from New: %v", err)
+	}
+	mux = http.NewServeMux()
+	res.Mount(mux)
+	serve(mux, http.MethodPost, "/friends/", jdoe)
+	codes := make(chan int, 10)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(codes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := httptest.NewRequest(http.MethodPut, "/friends/1", strings.NewReader(jdoe))
+			r.Header.Set("If-Match", `"1"`)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			codes <- w.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	counts := make(map[int]int)
+	for code := range codes {
+		counts[code]++
+	}
+	if counts[http.StatusOK] != 1 || counts[http.StatusPreconditionFailed] != cap(codes)-1 {
+		t.Error("Should let only one of concurrent updates of the same version succeed")
+		t.Errorf("Wanted one %v and the rest %v, got %v", http.StatusOK, http.StatusPreconditionFailed, counts)
+	}
+
+	// Should match any of several entity tags and never a weak one
+	tests := []struct {
+		match string
+		want  int
+	}{
+		{`W/"2"`, http.StatusPreconditionFailed},
+		{`"7", "2"`, http.StatusOK},
+		{`"2", "7"`, http.StatusPreconditionFailed},
+		{`*`, http.StatusOK},
+	}
+	for _, test := range tests {
+		r = httptest.NewRequest(http.MethodPut, "/friends/1", strings.NewReader(jdoe))
+		r.Header.Set("If-Match", test.match)
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != test.want {
+			t.Error("Should match any of several entity tags and never a weak one")
+			t.Errorf("Wanted %v, got %v %s for %v", test.want, w.Code, w.Body, test.match)
+		}
+	}
+
+	// Should not serve values without a version
+	res, err = New[friend]("/friends/", unversioned{NewMemory[friend]()})
+	if err != nil {
+		t.Fatalf("Unexpected error from New: %v", err)
+	}
+	mux = http.NewServeMux()
+	res.Mount(mux)
+	serve(mux, http.MethodPost, "/friends/", jdoe)
+	w = serve(mux, http.MethodGet, "/friends/1", "")
+	if w.Code != http.StatusInternalServerError {
+		t.Error("Should not serve values without a version")
+		t.Errorf("Wanted %v, got %v %s", http.StatusInternalServerError, w.Code, w.Body)
+	}
+}
+
+var errFull = errors.New("store is full")
+
+// full is a Store that cannot hold more values.
+type full struct{ *Memory[friend] }
+
+func (s full) Create(ctx context.Context, v friend) (Entry[friend], error) {
+	return Entry[friend]{}, errFull
+}
+
+// unversioned is a Store that does not version its values.
+type unversioned struct{ *Memory[friend] }
+
+func (s unversioned) Get(ctx context.Context, id string) (Entry[friend], error) {
+	e, err := s.Memory.Get(ctx, id)
+	e.Version = ""
+	return e, err
+}
+
+// racing is a Store whose values are updated by another writer after each Get.
+type racing struct{ *Memory[friend] }
+
+func (s racing) Get(ctx context.Context, id string) (Entry[friend], error) {
+	e, err := s.Memory.Get(ctx, id)
+	if err == nil {
+		_, err = s.Memory.Update(ctx, id, e.Value, "")
+	}
+	return e, err
+}
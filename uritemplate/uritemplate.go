@@ -0,0 +1,405 @@
+/*
+Package uritemplate implements URI Templates as defined in RFC 6570, up to and
+including level 4. A URI Template describes a range of URIs through variable
+expansion:
+
+	t, err := uritemplate.Parse("/orders/{id}{?fields*}")
+	...
+	uri, err := t.Expand(map[string]interface{}{
+		"id":     "1",
+		"fields": []string{"total", "status"},
+	})
+	// uri == "/orders/1?fields=total&fields=status"
+*/
+package uritemplate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidTemplate is returned when a template does not conform to the
+// syntax of RFC 6570.
+var ErrInvalidTemplate = errors.New("uritemplate: invalid template")
+
+// ErrPrefixComposite is returned when a prefix modifier is applied to a
+// variable whose value is a list or an associative array.
+var ErrPrefixComposite = errors.New("uritemplate: prefix modifier applied to composite value")
+
+// Template is a parsed URI Template.
+type Template struct {
+	raw   string
+	parts []part
+}
+
+// part is either a literal or an expression of a template.
+type part struct {
+	literal string
+	op      operator
+	vars    []varspec
+}
+
+type varspec struct {
+	name    string
+	prefix  int
+	explode bool
+}
+
+// operator describes the expansion of an expression, as given by the table in
+// appendix A of RFC 6570.
+type operator struct {
+	first    string
+	sep      string
+	named    bool
+	ifemp    string
+	reserved bool
+}
+
+var operators = map[byte]operator{
+	'+': {first: "", sep: ",", reserved: true},
+	'#': {first: "#", sep: ",", reserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifemp: "="},
+	'&': {first: "&", sep: "&", named: true, ifemp: "="},
+}
+
+var simple = operator{sep: ","}
+
+// Parse parses s as a URI Template. If s is not a valid template an error
+// wrapping ErrInvalidTemplate is returned.
+func Parse(s string) (Template, error) {
+	t := Template{raw: s}
+	for len(s) > 0 {
+		open := strings.IndexByte(s, '{')
+		if open < 0 {
+			open = len(s)
+		}
+		if strings.IndexByte(s[:open], '}') >= 0 {
+			return Template{}, fmt.Errorf("%w: unexpected '}'", ErrInvalidTemplate)
+		}
+		if open > 0 {
+			t.parts = append(t.parts, part{literal: s[:open]})
+		}
+		s = s[open:]
+		if s == "" {
+			break
+		}
+
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return Template{}, fmt.Errorf("%w: unclosed expression", ErrInvalidTemplate)
+		}
+		p, err := parseExpression(s[1:end])
+		if err != nil {
+			return Template{}, err
+		}
+		t.parts = append(t.parts, p)
+		s = s[end+1:]
+	}
+	return t, nil
+}
+
+// MustParse is like Parse but panics if s is not a valid template. It
+// simplifies the initialization of global variables holding templates.
+func MustParse(s string) Template {
+	t, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func parseExpression(expr string) (part, error) {
+	p := part{op: simple}
+	if expr == "" {
+		return p, fmt.Errorf("%w: empty expression", ErrInvalidTemplate)
+	}
+	if op, ok := operators[expr[0]]; ok {
+		p.op = op
+		expr = expr[1:]
+	} else if strings.IndexByte("=,!@|", expr[0]) >= 0 {
+		return p, fmt.Errorf("%w: reserved operator %q", ErrInvalidTemplate, expr[0])
+	}
+
+	for _, spec := range strings.Split(expr, ",") {
+		v := varspec{name: spec}
+		if strings.HasSuffix(spec, "*") {
+			v.name, v.explode = spec[:len(spec)-1], true
+		} else if i := strings.IndexByte(spec, ':'); i >= 0 {
+			n, err := strconv.Atoi(spec[i+1:])
+			if err != nil || n < 1 || n > 9999 || spec[i+1] == '+' {
+				return p, fmt.Errorf("%w: invalid prefix %q", ErrInvalidTemplate, spec)
+			}
+			v.name, v.prefix = spec[:i], n
+		}
+		if !validName(v.name) {
+			return p, fmt.Errorf("%w: invalid variable name %q", ErrInvalidTemplate, v.name)
+		}
+		p.vars = append(p.vars, v)
+	}
+	return p, nil
+}
+
+// validName reports whether name is a varname, which consists of ALPHA, DIGIT,
+// "_", and pct-encoded characters, with single dots between them.
+func validName(name string) bool {
+	if name == "" || name[0] == '.' || name[len(name)-1] == '.' || strings.Contains(name, "..") {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case isAlpha(c), isDigit(c), c == '_', c == '.':
+		case c == '%':
+			if i+2 >= len(name) || !isHex(name[i+1]) || !isHex(name[i+2]) {
+				return false
+			}
+			i += 2
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the template as it was given to Parse.
+func (t Template) String() string {
+	return t.raw
+}
+
+// Varnames returns the names of the variables of the template, in the order
+// they first appear.
+func (t Template) Varnames() []string {
+	var names []string
+	seen := make(map[string]struct{})
+	for _, p := range t.parts {
+		for _, v := range p.vars {
+			if _, ok := seen[v.name]; ok {
+				continue
+			}
+			seen[v.name] = struct{}{}
+			names = append(names, v.name)
+		}
+	}
+	return names
+}
+
+// Expand expands the template using the values of vars. A value can be a
+// string, a list given as a []string, or an associative array given as a
+// map[string]string, whose pairs are expanded in the order of their keys. Any
+// other value is formatted with fmt.Sprint. Variables that are missing, nil,
+// or an empty list or map are undefined and are left out of the expansion.
+func (t Template) Expand(vars map[string]interface{}) (string, error) {
+	var sb strings.Builder
+	for _, p := range t.parts {
+		if p.vars == nil {
+			encode(&sb, p.literal, true)
+			continue
+		}
+		err := p.expand(&sb, vars)
+		if err != nil {
+			return "", err
+		}
+	}
+	return sb.String(), nil
+}
+
+// Expand parses and expands the template s using the values of vars.
+func Expand(s string, vars map[string]interface{}) (string, error) {
+	t, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return t.Expand(vars)
+}
+
+func (p part) expand(sb *strings.Builder, vars map[string]interface{}) error {
+	op := p.op
+	first := true
+	for _, v := range p.vars {
+		val, ok := value(vars[v.name])
+		if !ok {
+			continue
+		}
+		if first {
+			sb.WriteString(op.first)
+			first = false
+		} else {
+			sb.WriteString(op.sep)
+		}
+
+		switch val := val.(type) {
+		case string:
+			if op.named {
+				sb.WriteString(v.name)
+				if val == "" {
+					sb.WriteString(op.ifemp)
+					continue
+				}
+				sb.WriteByte('=')
+			}
+			if v.prefix > 0 {
+				val = prefix(val, v.prefix)
+			}
+			encode(sb, val, op.reserved)
+		case []string:
+			if v.prefix > 0 {
+				return ErrPrefixComposite
+			}
+			p.expandList(sb, v, val)
+		case [][2]string:
+			if v.prefix > 0 {
+				return ErrPrefixComposite
+			}
+			p.expandPairs(sb, v, val)
+		}
+	}
+	return nil
+}
+
+func (p part) expandList(sb *strings.Builder, v varspec, list []string) {
+	op := p.op
+	if !v.explode {
+		if op.named {
+			sb.WriteString(v.name)
+			sb.WriteByte('=')
+		}
+		for i, item := range list {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			encode(sb, item, op.reserved)
+		}
+		return
+	}
+	for i, item := range list {
+		if i > 0 {
+			sb.WriteString(op.sep)
+		}
+		if op.named {
+			sb.WriteString(v.name)
+			if item == "" {
+				sb.WriteString(op.ifemp)
+				continue
+			}
+			sb.WriteByte('=')
+		}
+		encode(sb, item, op.reserved)
+	}
+}
+
+func (p part) expandPairs(sb *strings.Builder, v varspec, pairs [][2]string) {
+	op := p.op
+	if !v.explode {
+		if op.named {
+			sb.WriteString(v.name)
+			sb.WriteByte('=')
+		}
+		for i, pair := range pairs {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			encode(sb, pair[0], op.reserved)
+			sb.WriteByte(',')
+			encode(sb, pair[1], op.reserved)
+		}
+		return
+	}
+	for i, pair := range pairs {
+		if i > 0 {
+			sb.WriteString(op.sep)
+		}
+		encode(sb, pair[0], op.reserved)
+		if op.named && pair[1] == "" {
+			sb.WriteString(op.ifemp)
+			continue
+		}
+		sb.WriteByte('=')
+		encode(sb, pair[1], op.reserved)
+	}
+}
+
+// value normalizes the value of a variable into a string, a []string, or a
+// [][2]string holding the pairs of an associative array. It returns false if
+// the variable is undefined.
+func value(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case nil:
+		return nil, false
+	case string:
+		return v, true
+	case []string:
+		return v, len(v) > 0
+	case map[string]string:
+		if len(v) == 0 {
+			return nil, false
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([][2]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = [2]string{k, v[k]}
+		}
+		return pairs, true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// prefix returns the first n characters of s.
+func prefix(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	i := 0
+	for ; n > 0; n-- {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return s[:i]
+}
+
+const hex = "0123456789ABCDEF"
+
+// encode writes s to sb, percent-encoding every character that is not
+// unreserved. If reserved is true, reserved characters and pct-encoded
+// triplets are written as is.
+func encode(sb *strings.Builder, s string, reserved bool) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isUnreserved(c):
+			sb.WriteByte(c)
+		case reserved && isReserved(c):
+			sb.WriteByte(c)
+		case reserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			sb.WriteString(s[i : i+3])
+			i += 2
+		default:
+			sb.WriteByte('%')
+			sb.WriteByte(hex[c>>4])
+			sb.WriteByte(hex[c&15])
+		}
+	}
+}
+
+func isAlpha(c byte) bool { return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' }
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+func isHex(c byte) bool   { return isDigit(c) || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F' }
+
+func isUnreserved(c byte) bool {
+	return isAlpha(c) || isDigit(c) || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isReserved(c byte) bool {
+	return strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0
+}
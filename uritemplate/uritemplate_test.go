@@ -0,0 +1,202 @@
+package uritemplate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// exampleVars are the example variables of section 3.2 of RFC 6570.
+var exampleVars = map[string]interface{}{
+	"count":      []string{"one", "two", "three"},
+	"dom":        []string{"example", "com"},
+	"dub":        "me/too",
+	"hello":      "Hello World!",
+	"half":       "50%",
+	"var":        "value",
+	"who":        "fred",
+	"base":       "http://example.com/home/",
+	"path":       "/foo/bar",
+	"list":       []string{"red", "green", "blue"},
+	"keys":       map[string]string{"semi": ";", "dot": ".", "comma": ","},
+	"v":          6,
+	"x":          "1024",
+	"y":          "768",
+	"empty":      "",
+	"empty_keys": map[string]string{},
+	"undef":      nil,
+	"id":         "ééé",
+}
+
+func TestExpand(t *testing.T) {
+	// The expected expansions are those of RFC 6570, with the pairs of keys
+	// in the order of their keys.
+	tests := []struct {
+		template string
+		want     string
+	}{
+		// Level 1
+		{"{var}", "value"},
+		{"{hello}", "Hello%20World%21"},
+		{"{half}", "50%25"},
+		{"O{empty}X", "OX"},
+		{"O{undef}X", "OX"},
+		{"{x,y}", "1024,768"},
+		{"{x,hello,y}", "1024,Hello%20World%21,768"},
+		{"?{x,empty}", "?1024,"},
+		{"?{x,undef}", "?1024"},
+		{"?{undef,y}", "?768"},
+		{"{var:3}", "val"},
+		{"{var:30}", "value"},
+		{"{list}", "red,green,blue"},
+		{"{list*}", "red,green,blue"},
+		{"{keys}", "comma,%2C,dot,.,semi,%3B"},
+		{"{keys*}", "comma=%2C,dot=.,semi=%3B"},
+		// Reserved expansion
+		{"{+var}", "value"},
+		{"{+hello}", "Hello%20World!"},
+		{"{+half}", "50%25"},
+		{"{base}index", "http%3A%2F%2Fexample.com%2Fhome%2Findex"},
+		{"{+base}index", "http://example.com/home/index"},
+		{"O{+empty}X", "OX"},
+		{"{+path}/here", "/foo/bar/here"},
+		{"here?ref={+path}", "here?ref=/foo/bar"},
+		{"up{+path}{var}/here", "up/foo/barvalue/here"},
+		{"{+x,hello,y}", "1024,Hello%20World!,768"},
+		{"{+path,x}/here", "/foo/bar,1024/here"},
+		{"{+path:6}/here", "/foo/b/here"},
+		{"{+list}", "red,green,blue"},
+		{"{+keys}", "comma,,,dot,.,semi,;"},
+		{"{+keys*}", "comma=,,dot=.,semi=;"},
+		// Fragment expansion
+		{"{#var}", "#value"},
+		{"{#hello}", "#Hello%20World!"},
+		{"{#half}", "#50%25"},
+		{"foo{#empty}", "foo#"},
+		{"foo{#undef}", "foo"},
+		{"{#x,hello,y}", "#1024,Hello%20World!,768"},
+		{"{#path,x}/here", "#/foo/bar,1024/here"},
+		{"{#path:6}/here", "#/foo/b/here"},
+		{"{#list}", "#red,green,blue"},
+		{"{#list*}", "#red,green,blue"},
+		{"{#keys}", "#comma,,,dot,.,semi,;"},
+		{"{#keys*}", "#comma=,,dot=.,semi=;"},
+		// Label expansion
+		{"{.who}", ".fred"},
+		{"{.who,who}", ".fred.fred"},
+		{"{.half,who}", ".50%25.fred"},
+		{"www{.dom*}", "www.example.com"},
+		{"X{.var}", "X.value"},
+		{"X{.empty}", "X."},
+		{"X{.undef}", "X"},
+		{"X{.var:3}", "X.val"},
+		{"X{.list}", "X.red,green,blue"},
+		{"X{.list*}", "X.red.green.blue"},
+		{"X{.keys}", "X.comma,%2C,dot,.,semi,%3B"},
+		{"X{.keys*}", "X.comma=%2C.dot=..semi=%3B"},
+		{"X{.empty_keys}", "X"},
+		{"X{.empty_keys*}", "X"},
+		// Path segment expansion
+		{"{/who}", "/fred"},
+		{"{/who,who}", "/fred/fred"},
+		{"{/half,who}", "/50%25/fred"},
+		{"{/who,dub}", "/fred/me%2Ftoo"},
+		{"{/var}", "/value"},
+		{"{/var,empty}", "/value/"},
+		{"{/var,undef}", "/value"},
+		{"{/var,x}/here", "/value/1024/here"},
+		{"{/var:1,var}", "/v/value"},
+		{"{/list}", "/red,green,blue"},
+		{"{/list*}", "/red/green/blue"},
+		{"{/list*,path:4}", "/red/green/blue/%2Ffoo"},
+		{"{/keys}", "/comma,%2C,dot,.,semi,%3B"},
+		{"{/keys*}", "/comma=%2C/dot=./semi=%3B"},
+		// Path-style parameter expansion
+		{"{;who}", ";who=fred"},
+		{"{;half}", ";half=50%25"},
+		{"{;empty}", ";empty"},
+		{"{;v,empty,who}", ";v=6;empty;who=fred"},
+		{"{;v,bar,who}", ";v=6;who=fred"},
+		{"{;x,y}", ";x=1024;y=768"},
+		{"{;x,y,empty}", ";x=1024;y=768;empty"},
+		{"{;x,y,undef}", ";x=1024;y=768"},
+		{"{;hello:5}", ";hello=Hello"},
+		{"{;list}", ";list=red,green,blue"},
+		{"{;list*}", ";list=red;list=green;list=blue"},
+		{"{;keys}", ";keys=comma,%2C,dot,.,semi,%3B"},
+		{"{;keys*}", ";comma=%2C;dot=.;semi=%3B"},
+		// Form-style query expansion
+		{"{?who}", "?who=fred"},
+		{"{?half}", "?half=50%25"},
+		{"{?x,y}", "?x=1024&y=768"},
+		{"{?x,y,empty}", "?x=1024&y=768&empty="},
+		{"{?x,y,undef}", "?x=1024&y=768"},
+		{"{?var:3}", "?var=val"},
+		{"{?list}", "?list=red,green,blue"},
+		{"{?list*}", "?list=red&list=green&list=blue"},
+		{"{?keys}", "?keys=comma,%2C,dot,.,semi,%3B"},
+		{"{?keys*}", "?comma=%2C&dot=.&semi=%3B"},
+		// Form-style query continuation
+		{"{&who}", "&who=fred"},
+		{"{&half}", "&half=50%25"},
+		{"?fixed=yes{&x}", "?fixed=yes&x=1024"},
+		{"{&x,y,empty}", "&x=1024&y=768&empty="},
+		{"{&var:3}", "&var=val"},
+		{"{&list}", "&list=red,green,blue"},
+		{"{&list*}", "&list=red&list=green&list=blue"},
+		{"{&keys}", "&keys=comma,%2C,dot,.,semi,%3B"},
+		{"{&keys*}", "&comma=%2C&dot=.&semi=%3B"},
+		// Prefixes count characters, not bytes
+		{"{var:2}", "va"},
+		{"/orders/{id:2}", "/orders/%C3%A9%C3%A9"},
+	}
+	for _, test := range tests {
+		got, err := Expand(test.template, exampleVars)
+		if err != nil {
+			t.Errorf("Unexpected error expanding %q: %v", test.template, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Wanted %v, got %v for %q", test.want, got, test.template)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	// Should not be able to parse invalid templates
+	for _, s := range []string{"{", "}", "{}", "{var", "a}b{c}", "{=var}", "{var:0}", "{var:10000}", "{var:+1}", "{va r}", "{.var.}", "{a..b}", "{var,}"} {
+		_, err := Parse(s)
+		if !errors.Is(err, ErrInvalidTemplate) {
+			t.Error("Should not be able to parse invalid templates")
+			t.Errorf("Wanted %v, got %v for %q", ErrInvalidTemplate, err, s)
+		}
+	}
+
+	// Should list the variable names of a template
+	tmpl := MustParse("/orders/{id}{?fields*,id}{&page.size,%41}")
+	want := []string{"id", "fields", "page.size", "%41"}
+	if got := tmpl.Varnames(); !reflect.DeepEqual(want, got) {
+		t.Error("Should list the variable names of a template")
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+	if tmpl.String() != "/orders/{id}{?fields*,id}{&page.size,%41}" {
+		t.Errorf("Wanted %v, got %v", "/orders/{id}{?fields*,id}{&page.size,%41}", tmpl.String())
+	}
+
+	// Should leave out undefined variables
+	got, err := tmpl.Expand(nil)
+	if err != nil {
+		t.Errorf("Unexpected error from Expand: %v", err)
+	}
+	if got != "/orders/" {
+		t.Error("Should leave out undefined variables")
+		t.Errorf("Wanted %v, got %v", "/orders/", got)
+	}
+
+	// Should not be able to apply a prefix to a composite value
+	_, err = Expand("{list:3}", exampleVars)
+	if err != ErrPrefixComposite {
+		t.Error("Should not be able to apply a prefix to a composite value")
+		t.Errorf("Wanted %v, got %v", ErrPrefixComposite, err)
+	}
+}